@@ -0,0 +1,83 @@
+package ccid
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	insSelect      = 0xA4
+	insGetResponse = 0xC0
+
+	swSuccess   = 0x9000
+	swMoreData  = 0x61
+	swNotFound  = 0x6A82
+	swInsNotSup = 0x6D00
+)
+
+var ErrNotFound = errors.New("applet not found")
+
+// StatusError is a non-success status word returned by the card.
+type StatusError struct {
+	SW uint16
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("card status: 0x%04x", e.SW)
+}
+
+type APDU struct {
+	CLA  byte
+	INS  byte
+	P1   byte
+	P2   byte
+	Data []byte
+}
+
+func (a APDU) Bytes() []byte {
+	out := []byte{a.CLA, a.INS, a.P1, a.P2}
+	if len(a.Data) > 0 {
+		out = append(out, byte(len(a.Data)))
+		out = append(out, a.Data...)
+	}
+
+	return out
+}
+
+// Send transmits the command and collects the whole response, following "more data" status words.
+func Send(card Card, cmd APDU) ([]byte, error) {
+	apdu := cmd.Bytes()
+	var out []byte
+	for {
+		rsp, err := card.Transmit(apdu)
+		if err != nil {
+			return nil, fmt.Errorf("transmit: %w", err)
+		}
+
+		if len(rsp) < 2 {
+			return nil, fmt.Errorf("response is too short: %d", len(rsp))
+		}
+
+		data, sw1, sw2 := rsp[:len(rsp)-2], rsp[len(rsp)-2], rsp[len(rsp)-1]
+		out = append(out, data...)
+		sw := uint16(sw1)<<8 | uint16(sw2)
+		switch {
+		case sw == swSuccess:
+			return out, nil
+		case sw1 == swMoreData:
+			apdu = APDU{INS: insGetResponse}.Bytes()
+		case sw == swNotFound:
+			return nil, ErrNotFound
+		default:
+			return nil, &StatusError{SW: sw}
+		}
+	}
+}
+
+func Select(card Card, aid []byte) ([]byte, error) {
+	return Send(card, APDU{
+		INS:  insSelect,
+		P1:   0x04,
+		Data: aid,
+	})
+}
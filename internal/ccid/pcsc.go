@@ -0,0 +1,92 @@
+package ccid
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	pcsc "github.com/gballet/go-libpcsclite"
+)
+
+var _ Transport = (*PCSC)(nil)
+
+// PCSC talks to the pcsc-lite daemon over its unix socket.
+// Every operation establishes its own context, so a restarted daemon is picked up transparently.
+type PCSC struct {
+	socket string
+}
+
+func NewPCSC(socket string) *PCSC {
+	if socket == "" {
+		socket = pcsc.PCSCDSockName
+	}
+
+	return &PCSC{
+		socket: socket,
+	}
+}
+
+func (p *PCSC) Readers() ([]string, error) {
+	client, err := pcsc.EstablishContext(p.socket, pcsc.ScopeSystem)
+	if err != nil {
+		return nil, fmt.Errorf("establish context: %w", err)
+	}
+	defer func() { _ = client.ReleaseContext() }()
+
+	readers, err := client.ListReaders()
+	if err != nil {
+		return nil, fmt.Errorf("list readers: %w", err)
+	}
+
+	out := make([]string, 0, len(readers))
+	for _, reader := range readers {
+		out = append(out, strings.TrimRight(reader, "\x00"))
+	}
+
+	return out, nil
+}
+
+func (p *PCSC) Connect(reader string, mode ShareMode) (Card, error) {
+	client, err := pcsc.EstablishContext(p.socket, pcsc.ScopeSystem)
+	if err != nil {
+		return nil, fmt.Errorf("establish context: %w", err)
+	}
+
+	shareMode := uint32(pcsc.ShareShared)
+	if mode == ShareExclusive {
+		shareMode = pcsc.ShareExclusive
+	}
+
+	card, err := client.Connect(reader, shareMode, pcsc.ProtocolT1)
+	if err != nil {
+		_ = client.ReleaseContext()
+		return nil, fmt.Errorf("connect to %q: %w", reader, err)
+	}
+
+	return &pcscCard{
+		client: client,
+		card:   card,
+	}, nil
+}
+
+type pcscCard struct {
+	client *pcsc.Client
+	card   *pcsc.Card
+}
+
+func (c *pcscCard) Transmit(apdu []byte) ([]byte, error) {
+	rsp, _, err := c.card.Transmit(apdu)
+	return rsp, err
+}
+
+func (c *pcscCard) Close(reset bool) error {
+	disposition := uint32(pcsc.LeaveCard)
+	if reset {
+		disposition = pcsc.UnpowerCard
+	}
+
+	return errors.Join(
+		c.card.Disconnect(disposition),
+		c.client.ReleaseContext(),
+	)
+}
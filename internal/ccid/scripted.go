@@ -0,0 +1,205 @@
+package ccid
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/buglloc/fidoctl"
+)
+
+var _ Transport = (*ScriptedTransport)(nil)
+
+var ErrSharingViolation = errors.New("card is used by another application")
+
+// Exchange is a single scripted command and the response to it, status word included.
+type Exchange struct {
+	Command  []byte
+	Response []byte
+}
+
+// Script answers every command with the response of the first matching exchange,
+// and with "INS not supported" when nothing matches.
+type Script struct {
+	Exchanges []Exchange
+	mu        sync.Mutex
+	commands  [][]byte
+	resets    int
+	holder    *ShareMode
+}
+
+// Hold makes the card look opened by another application in the given mode, until Release.
+func (s *Script) Hold(mode ShareMode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.holder = &mode
+}
+
+func (s *Script) Release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.holder = nil
+}
+
+// Commands returns every command received so far.
+func (s *Script) Commands() [][]byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([][]byte, len(s.commands))
+	copy(out, s.commands)
+	return out
+}
+
+func (s *Script) Resets() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.resets
+}
+
+func (s *Script) respond(apdu []byte) []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.commands = append(s.commands, bytes.Clone(apdu))
+	for _, e := range s.Exchanges {
+		if bytes.Equal(e.Command, apdu) {
+			return e.Response
+		}
+	}
+
+	return []byte{swInsNotSup >> 8, swInsNotSup & 0xff}
+}
+
+// ScriptedTransport is an in-memory Transport whose readers answer from scripts.
+type ScriptedTransport struct {
+	mu      sync.Mutex
+	readers map[string]*Script
+	order   []string
+}
+
+func NewScriptedTransport() *ScriptedTransport {
+	return &ScriptedTransport{
+		readers: make(map[string]*Script),
+	}
+}
+
+func (t *ScriptedTransport) AddReader(reader string, script *Script) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if _, ok := t.readers[reader]; !ok {
+		t.order = append(t.order, reader)
+	}
+	t.readers[reader] = script
+}
+
+func (t *ScriptedTransport) RemoveReader(reader string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.readers, reader)
+	for i, r := range t.order {
+		if r == reader {
+			t.order = append(t.order[:i], t.order[i+1:]...)
+			break
+		}
+	}
+}
+
+func (t *ScriptedTransport) Readers() ([]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]string(nil), t.order...), nil
+}
+
+func (t *ScriptedTransport) Connect(reader string, mode ShareMode) (Card, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	script, ok := t.readers[reader]
+	if !ok {
+		return nil, fmt.Errorf("connect to %q: no such reader", reader)
+	}
+
+	script.mu.Lock()
+	holder := script.holder
+	script.mu.Unlock()
+
+	if holder != nil && (*holder == ShareExclusive || mode == ShareExclusive) {
+		return nil, fmt.Errorf("connect to %q: %w", reader, ErrSharingViolation)
+	}
+
+	return &scriptedCard{
+		script: script,
+	}, nil
+}
+
+type scriptedCard struct {
+	script *Script
+	closed bool
+}
+
+func (c *scriptedCard) Transmit(apdu []byte) ([]byte, error) {
+	if c.closed {
+		return nil, errors.New("card is disconnected")
+	}
+
+	return c.script.respond(apdu), nil
+}
+
+func (c *scriptedCard) Close(reset bool) error {
+	c.closed = true
+	if reset {
+		c.script.mu.Lock()
+		c.script.resets++
+		c.script.mu.Unlock()
+	}
+
+	return nil
+}
+
+// YubikeyScript scripts a Yubikey that reports the given serial and firmware version
+// through both the management and the PIV applets.
+func YubikeyScript(serial uint32, major, minor, patch byte) *Script {
+	ok := []byte{swSuccess >> 8, swSuccess & 0xff}
+	serialBytes := binary.BigEndian.AppendUint32(nil, serial)
+
+	var cfg fidoctl.YubiConfig
+	cfg.Set(fidoctl.ConfigTagSerial, serialBytes)
+	cfg.Set(fidoctl.ConfigTagVersion, []byte{major, minor, patch})
+	// CCID only: PIV, OATH, OpenPGP
+	cfg.Set(fidoctl.ConfigTagUsbEnabled, []byte{0x00, 0x38})
+	rawCfg, _ := cfg.Marshal()
+
+	return &Script{
+		Exchanges: []Exchange{
+			{
+				Command:  APDU{INS: insSelect, P1: 0x04, Data: AIDManagement}.Bytes(),
+				Response: append([]byte(fmt.Sprintf("%d.%d.%d", major, minor, patch)), ok...),
+			},
+			{
+				Command:  APDU{INS: insReadConfig}.Bytes(),
+				Response: append(rawCfg, ok...),
+			},
+			{
+				Command:  APDU{INS: insSelect, P1: 0x04, Data: AIDPIV}.Bytes(),
+				Response: ok,
+			},
+			{
+				Command:  APDU{INS: insPIVVersion}.Bytes(),
+				Response: append([]byte{major, minor, patch}, ok...),
+			},
+			{
+				Command:  APDU{INS: insPIVSerial}.Bytes(),
+				Response: append(serialBytes, ok...),
+			},
+		},
+	}
+}
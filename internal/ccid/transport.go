@@ -0,0 +1,23 @@
+package ccid
+
+type ShareMode int
+
+const (
+	// ShareShared lets other applications use the card meanwhile, enough to read it.
+	ShareShared ShareMode = iota
+	// ShareExclusive locks the card for the connection, e.g. to power cycle it.
+	ShareExclusive
+)
+
+// Transport gives access to the smart card readers of the host.
+type Transport interface {
+	Readers() ([]string, error)
+	Connect(reader string, mode ShareMode) (Card, error)
+}
+
+// Card is a connection to the card in a reader.
+type Card interface {
+	Transmit(apdu []byte) ([]byte, error)
+	// Close disconnects from the card, power cycling it if reset is set.
+	Close(reset bool) error
+}
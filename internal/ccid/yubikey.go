@@ -0,0 +1,96 @@
+package ccid
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/buglloc/fidoctl"
+)
+
+var (
+	AIDManagement = []byte{0xa0, 0x00, 0x00, 0x05, 0x27, 0x47, 0x11, 0x17}
+	AIDPIV        = []byte{0xa0, 0x00, 0x00, 0x03, 0x08}
+)
+
+const (
+	insReadConfig = 0x1D
+	insPIVVersion = 0xFD
+	insPIVSerial  = 0xF8
+)
+
+// IsYubikeyReader reports whether the reader looks like a Yubikey CCID interface.
+func IsYubikeyReader(reader string) bool {
+	reader = strings.ToLower(reader)
+	return strings.Contains(reader, "yubico") || strings.Contains(reader, "yubikey")
+}
+
+// ReadConfig reads the Yubikey device config through the management applet,
+// falling back to the serial and version reported by the PIV applet.
+func ReadConfig(card Card) (*fidoctl.YubiConfig, error) {
+	cfg, mgmtErr := readManagementConfig(card)
+	if mgmtErr == nil && cfg.Serial() != 0 {
+		return cfg, nil
+	}
+
+	cfg, pivErr := readPIVConfig(card)
+	if pivErr != nil {
+		return nil, errors.Join(mgmtErr, pivErr)
+	}
+
+	return cfg, nil
+}
+
+func readManagementConfig(card Card) (*fidoctl.YubiConfig, error) {
+	if _, err := Select(card, AIDManagement); err != nil {
+		return nil, fmt.Errorf("select management: %w", err)
+	}
+
+	data, err := Send(card, APDU{INS: insReadConfig})
+	if err != nil {
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+
+	if len(data) == 0 {
+		return nil, errors.New("read config: empty response")
+	}
+
+	var cfg fidoctl.YubiConfig
+	if err := cfg.Unmarshal(data); err != nil {
+		return nil, fmt.Errorf("parse config: %w", err)
+	}
+
+	return &cfg, nil
+}
+
+func readPIVConfig(card Card) (*fidoctl.YubiConfig, error) {
+	if _, err := Select(card, AIDPIV); err != nil {
+		return nil, fmt.Errorf("select PIV: %w", err)
+	}
+
+	version, err := Send(card, APDU{INS: insPIVVersion})
+	if err != nil {
+		return nil, fmt.Errorf("get PIV version: %w", err)
+	}
+
+	if len(version) != 3 {
+		return nil, fmt.Errorf("invalid PIV version length: %d", len(version))
+	}
+
+	serial, err := Send(card, APDU{INS: insPIVSerial})
+	if err != nil {
+		return nil, fmt.Errorf("get PIV serial: %w", err)
+	}
+
+	if len(serial) != 4 || binary.BigEndian.Uint32(serial) == 0 {
+		return nil, fmt.Errorf("invalid PIV serial: %x", serial)
+	}
+
+	var cfg fidoctl.YubiConfig
+	cfg.Set(fidoctl.ConfigTagSerial, serial)
+	cfg.Set(fidoctl.ConfigTagVersion, version)
+	// at least PIV is enabled, since we've just talked to it
+	cfg.Set(fidoctl.ConfigTagUsbEnabled, []byte{0x00, 0x10})
+	return &cfg, nil
+}
@@ -0,0 +1,79 @@
+package ccid_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/ccid"
+)
+
+const reader = "Yubico YubiKey CCID 00 00"
+
+func TestReadConfig_Management(t *testing.T) {
+	transport := ccid.NewScriptedTransport()
+	transport.AddReader(reader, ccid.YubikeyScript(12345678, 5, 4, 3))
+
+	card, err := transport.Connect(reader, ccid.ShareShared)
+	require.NoError(t, err)
+	defer func() { _ = card.Close(false) }()
+
+	cfg, err := ccid.ReadConfig(card)
+	require.NoError(t, err)
+	require.EqualValues(t, 12345678, cfg.Serial())
+	require.Equal(t, "5.4.3", cfg.Version().String())
+}
+
+func TestReadConfig_PIVFallback(t *testing.T) {
+	// older firmware without the management config command
+	script := ccid.YubikeyScript(7654321, 4, 3, 7)
+	script.Exchanges = script.Exchanges[2:]
+
+	transport := ccid.NewScriptedTransport()
+	transport.AddReader(reader, script)
+
+	card, err := transport.Connect(reader, ccid.ShareShared)
+	require.NoError(t, err)
+	defer func() { _ = card.Close(false) }()
+
+	cfg, err := ccid.ReadConfig(card)
+	require.NoError(t, err)
+	require.EqualValues(t, 7654321, cfg.Serial())
+	require.Equal(t, "4.3.7", cfg.Version().String())
+}
+
+func TestReadConfig_NotYubikey(t *testing.T) {
+	transport := ccid.NewScriptedTransport()
+	transport.AddReader(reader, &ccid.Script{})
+
+	card, err := transport.Connect(reader, ccid.ShareShared)
+	require.NoError(t, err)
+	defer func() { _ = card.Close(false) }()
+
+	_, err = ccid.ReadConfig(card)
+	require.Error(t, err)
+}
+
+func TestScriptedTransport_Sharing(t *testing.T) {
+	script := ccid.YubikeyScript(1, 5, 7, 1)
+	transport := ccid.NewScriptedTransport()
+	transport.AddReader(reader, script)
+
+	script.Hold(ccid.ShareShared)
+	card, err := transport.Connect(reader, ccid.ShareShared)
+	require.NoError(t, err)
+	require.NoError(t, card.Close(false))
+
+	_, err = transport.Connect(reader, ccid.ShareExclusive)
+	require.ErrorIs(t, err, ccid.ErrSharingViolation)
+
+	script.Hold(ccid.ShareExclusive)
+	_, err = transport.Connect(reader, ccid.ShareShared)
+	require.ErrorIs(t, err, ccid.ErrSharingViolation)
+
+	script.Release()
+	card, err = transport.Connect(reader, ccid.ShareExclusive)
+	require.NoError(t, err)
+	require.NoError(t, card.Close(true))
+	require.Equal(t, 1, script.Resets())
+}
@@ -0,0 +1,18 @@
+package commands
+
+import (
+	"github.com/buglloc/yubictld/internal/xnet"
+	"github.com/buglloc/yubictld/pkg/yubictl"
+)
+
+func newSvcClient() *yubictl.SvcClient {
+	upstream, transport := xnet.HTTPUpstream(cfg.Server.Addr)
+	opts := []yubictl.Option{
+		yubictl.WithAdminToken(cfg.Server.AdminToken),
+	}
+	if transport != nil {
+		opts = append(opts, yubictl.WithTransport(transport))
+	}
+
+	return yubictl.NewSvcClient(upstream, opts...)
+}
@@ -22,6 +22,10 @@ var listCmd = &cobra.Command{
 		for _, dev := range ykm.Devices() {
 			fmt.Printf("- %s:\n", dev.Path())
 			fmt.Printf("\tserial: %d\n", dev.Serial())
+			fmt.Printf("\tmodel: %s %s\n", dev.Vendor(), dev.Model())
+			fmt.Printf("\tversion: %s\n", dev.Version())
+			fmt.Printf("\tform factor: %s\n", dev.FormFactor())
+			fmt.Printf("\tinterfaces: %s\n", dev.Interfaces())
 			fmt.Printf("\tlocation: %v\n", dev.Location())
 			fmt.Printf("\tfree: %v\n", dev.IsFree())
 		}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+var powerCycleArgs struct {
+	serial uint32
+}
+
+var powerCycleCmd = &cobra.Command{
+	Use:           "power-cycle",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Short:         "Power cycle Yubikeys through their hub ports",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		ykm := ykman.NewYkMan(
+			ykman.WithSysfsRoot(cfg.YkMan.SysfsRoot),
+			ykman.WithPowerOffDelay(cfg.YkMan.PowerOffDelay),
+			ykman.WithRebootTimeout(cfg.YkMan.RebootTimeout),
+		)
+		if err := ykm.ReloadDevices(); err != nil {
+			return fmt.Errorf("could not reload yubikeys: %w", err)
+		}
+
+		for _, dev := range ykm.Devices() {
+			if powerCycleArgs.serial != 0 && dev.Serial() != powerCycleArgs.serial {
+				continue
+			}
+
+			downtime, err := ykm.PowerCycle(cmd.Context(), dev)
+			if err != nil {
+				fmt.Printf("could not power cycle %s: %v\n", dev.String(), err)
+				continue
+			}
+
+			fmt.Printf("%s was power cycled in %s\n", dev.String(), downtime)
+		}
+
+		return nil
+	},
+}
+
+func init() {
+	flags := powerCycleCmd.PersistentFlags()
+	flags.Uint32Var(&powerCycleArgs.serial, "serial", 0, "Yubikey serial, all Yubikeys if not set")
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var quarantineArgs struct {
+	serial uint32
+	reason string
+}
+
+var quarantineCmd = &cobra.Command{
+	Use:           "quarantine",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Short:         "Take Yubikey out of rotation",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if quarantineArgs.serial == 0 {
+			return fmt.Errorf("must specify a serial to quarantine")
+		}
+
+		yk, err := newSvcClient().Quarantine(cmd.Context(), quarantineArgs.serial, quarantineArgs.reason)
+		if err != nil {
+			return fmt.Errorf("quarantine yubikey: %w", err)
+		}
+
+		fmt.Printf("yubikey %d quarantined: %s\n", yk.Serial, yk.QuarantineReason)
+		return nil
+	},
+}
+
+var unquarantineArgs struct {
+	serial uint32
+}
+
+var unquarantineCmd = &cobra.Command{
+	Use:           "unquarantine",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Short:         "Return quarantined Yubikey to rotation",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		if unquarantineArgs.serial == 0 {
+			return fmt.Errorf("must specify a serial to unquarantine")
+		}
+
+		yk, err := newSvcClient().Unquarantine(cmd.Context(), unquarantineArgs.serial)
+		if err != nil {
+			return fmt.Errorf("unquarantine yubikey: %w", err)
+		}
+
+		fmt.Printf("yubikey %d returned to rotation\n", yk.Serial)
+		return nil
+	},
+}
+
+func init() {
+	flags := quarantineCmd.PersistentFlags()
+	flags.Uint32Var(&quarantineArgs.serial, "serial", 0, "Yubikey serial")
+	flags.StringVar(&quarantineArgs.reason, "reason", "manual", "Quarantine reason")
+
+	flags = unquarantineCmd.PersistentFlags()
+	flags.Uint32Var(&unquarantineArgs.serial, "serial", 0, "Yubikey serial")
+}
@@ -8,26 +8,45 @@ import (
 	"github.com/buglloc/yubictld/internal/ykman"
 )
 
+var rebootArgs struct {
+	strategy string
+}
+
 var rebootCmd = &cobra.Command{
 	Use:           "reboot",
 	SilenceUsage:  true,
 	SilenceErrors: true,
 	Short:         "Reboot Yubikeys",
-	RunE: func(_ *cobra.Command, _ []string) error {
-		ykm := ykman.NewYkMan()
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		var strategy ykman.RebootStrategyKind
+		if err := strategy.UnmarshalText([]byte(rebootArgs.strategy)); err != nil {
+			return err
+		}
+
+		ykm := ykman.NewYkMan(
+			ykman.WithSysfsRoot(cfg.YkMan.SysfsRoot),
+			ykman.WithDevRoot(cfg.YkMan.DevRoot),
+			ykman.WithRebootTimeout(cfg.YkMan.RebootTimeout),
+		)
 		if err := ykm.ReloadDevices(); err != nil {
 			return fmt.Errorf("could not reload yubikeys: %w", err)
 		}
 
 		for _, dev := range ykm.Devices() {
-			if err := dev.Reboot(); err != nil {
+			downtime, err := ykm.Reboot(cmd.Context(), dev, strategy)
+			if err != nil {
 				fmt.Printf("could not reboot %s: %v\n", dev.String(), err)
 				continue
 			}
 
-			fmt.Printf("%s was rebooted", dev.String())
+			fmt.Printf("%s was rebooted in %s\n", dev.String(), downtime)
 		}
 
 		return nil
 	},
 }
+
+func init() {
+	flags := rebootCmd.PersistentFlags()
+	flags.StringVar(&rebootArgs.strategy, "strategy", "fido", "Reboot strategy: fido, usb-reset or deauthorize")
+}
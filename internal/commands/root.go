@@ -38,6 +38,10 @@ func init() {
 		listCmd,
 		touchCmd,
 		rebootCmd,
+		powerCycleCmd,
+		statusCmd,
+		quarantineCmd,
+		unquarantineCmd,
 	)
 }
 
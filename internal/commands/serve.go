@@ -10,8 +10,15 @@ import (
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
+
+	"github.com/buglloc/yubictld/internal/simulator"
 )
 
+var serveArgs struct {
+	simulate      int
+	rebootLatency time.Duration
+}
+
 var serveCmd = &cobra.Command{
 	Use:           "serve",
 	SilenceUsage:  true,
@@ -23,11 +30,34 @@ var serveCmd = &cobra.Command{
 			return fmt.Errorf("create runtime: %w", err)
 		}
 
+		if serveArgs.simulate > 0 {
+			runtime.Simulate(serveArgs.simulate, simulator.WithRebootLatency(serveArgs.rebootLatency))
+			log.Warn().
+				Int("count", serveArgs.simulate).
+				Msg("serving simulated yubikeys")
+		}
+
 		srv, err := runtime.NewServer()
 		if err != nil {
 			return fmt.Errorf("create gateway: %w", err)
 		}
 
+		monitor, err := runtime.NewHotplugMonitor()
+		if err != nil {
+			return fmt.Errorf("create hotplug monitor: %w", err)
+		}
+
+		if monitor != nil {
+			monitorCtx, monitorCancel := context.WithCancel(context.Background())
+			defer monitorCancel()
+
+			go func() {
+				if err := monitor.Run(monitorCtx); err != nil {
+					log.Error().Err(err).Msg("hotplug monitor failed")
+				}
+			}()
+		}
+
 		errChan := make(chan error, 1)
 		okChan := make(chan struct{})
 		go func() {
@@ -49,7 +79,12 @@ var serveCmd = &cobra.Command{
 			ctx, cancel := context.WithTimeout(context.Background(), 1*time.Minute)
 			defer cancel()
 
-			return srv.Shutdown(ctx)
+			err := srv.Shutdown(ctx)
+			if yk, ykErr := runtime.YkMan(); ykErr == nil {
+				_ = yk.Close()
+			}
+
+			return err
 		case err := <-errChan:
 			log.Error().Err(err).Msg("start failed")
 			return err
@@ -58,3 +93,9 @@ var serveCmd = &cobra.Command{
 		return nil
 	},
 }
+
+func init() {
+	flags := serveCmd.PersistentFlags()
+	flags.IntVar(&serveArgs.simulate, "simulate", 0, "Serve N simulated Yubikeys instead of real ones")
+	flags.DurationVar(&serveArgs.rebootLatency, "simulate-reboot-latency", simulator.DefaultRebootLatency, "Reboot latency of simulated Yubikeys")
+}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var statusCmd = &cobra.Command{
+	Use:           "status",
+	SilenceUsage:  true,
+	SilenceErrors: true,
+	Short:         "Show Yubikeys status reported by daemon",
+	RunE: func(cmd *cobra.Command, _ []string) error {
+		yks, err := newSvcClient().Yubikeys(cmd.Context())
+		if err != nil {
+			return fmt.Errorf("could not get yubikeys: %w", err)
+		}
+
+		for _, yk := range yks {
+			fmt.Printf("- %d:\n", yk.Serial)
+			fmt.Printf("\tmodel: %s %s\n", yk.Vendor, yk.Model)
+			fmt.Printf("\tpath: %s\n", yk.Path)
+			fmt.Printf("\tversion: %s\n", yk.Version)
+			fmt.Printf("\tlocation: %s\n", yk.Location)
+			if yk.Toucher != "" {
+				fmt.Printf("\ttoucher: %s\n", yk.Toucher)
+			}
+			fmt.Printf("\tport: %d\n", yk.Port)
+			fmt.Printf("\tleased: %v\n", yk.Leased)
+			fmt.Printf("\tcleaning: %v\n", yk.Cleaning)
+			fmt.Printf("\tfailures: %d\n", yk.Failures)
+			if !yk.MissingSince.IsZero() {
+				fmt.Printf("\tmissing since: %s\n", yk.MissingSince)
+			}
+			if yk.Quarantined {
+				fmt.Printf("\tquarantined: %s (since %s)\n", yk.QuarantineReason, yk.QuarantinedAt)
+			}
+		}
+
+		return nil
+	},
+}
@@ -25,9 +25,10 @@ func (c *Config) Validate() error {
 }
 
 type Runtime struct {
-	cfg     *Config
-	toucher touchctl.Toucher
-	ykman   *ykman.YkMan
+	cfg        *Config
+	touchers   *touchctl.Set
+	enumerator ykman.Enumerator
+	ykman      *ykman.YkMan
 }
 
 func LoadConfig(files ...string) (*Config, error) {
@@ -36,14 +37,26 @@ func LoadConfig(files ...string) (*Config, error) {
 			Addr: httpd.DefaultAddr,
 		},
 		Touch: TouchCfg{
-			Kind: touchctl.ToucherKindH4ptix,
+			ToucherCfg: ToucherCfg{
+				Kind: touchctl.ToucherKindH4ptix,
+			},
 		},
 		YkMan: YkManCfg{
-			LockTTL:   time.Hour,
-			Discovery: ykman.DiscoveryKindToucher,
+			LockTTL:       time.Hour,
+			MissingGrace:  ykman.DefaultMissingGrace,
+			RebootTimeout: ykman.DefaultRebootTimeout,
+			PowerOffDelay: ykman.DefaultPowerOffDelay,
+			SysfsRoot:     ykman.DefaultSysfsRoot,
+			DevRoot:       ykman.DefaultDevRoot,
+			Discovery:     ykman.DiscoveryKindToucher,
 		},
 	}
 
+	out.YkMan.Health.MaxFailures = ykman.DefaultMaxFailures
+	out.YkMan.Release.Timeout = ykman.DefaultCleanupTimeout
+	out.YkMan.Release.RebootWait = ykman.DefaultRebootWait
+	out.YkMan.Release.YkmanPath = ykman.DefaultYkmanPath
+
 	k := koanf.New(".")
 	if err := k.Load(env.Provider("YUBICTL", "_", nil), nil); err != nil {
 		return nil, fmt.Errorf("load env config: %w", err)
@@ -7,7 +7,8 @@ import (
 )
 
 type ServerCfg struct {
-	Addr string `koanf:"addr"`
+	Addr       string `koanf:"addr"`
+	AdminToken string `koanf:"admin_token"`
 }
 
 func (r *Runtime) NewServer() (*httpd.Server, error) {
@@ -16,7 +17,7 @@ func (r *Runtime) NewServer() (*httpd.Server, error) {
 		return nil, fmt.Errorf("create ykman runtime: %w", err)
 	}
 
-	touch, err := r.Toucher()
+	touchers, err := r.Touchers()
 	if err != nil {
 		return nil, fmt.Errorf("create touch runtime: %w", err)
 	}
@@ -24,6 +25,7 @@ func (r *Runtime) NewServer() (*httpd.Server, error) {
 	return httpd.NewServer(
 		httpd.WithAddr(r.cfg.Server.Addr),
 		httpd.WithYkMan(yk),
-		httpd.WithToucher(touch),
+		httpd.WithTouchers(touchers),
+		httpd.WithAdminToken(r.cfg.Server.AdminToken),
 	)
 }
@@ -2,35 +2,174 @@ package config
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/buglloc/yubictld/internal/touchctl"
 )
 
+const DefaultToucherID = "default"
+
+// TouchCfg configures either a single toucher inline or the list of touchers.
 type TouchCfg struct {
+	ToucherCfg `koanf:",squash"`
+	Touchers   []ToucherCfg `koanf:"touchers"`
+}
+
+type ToucherCfg struct {
+	ID     string               `koanf:"id"`
 	Kind   touchctl.ToucherKind `koanf:"kind"`
 	H4ptix struct {
 		Serial string `koanf:"serial"`
 	} `koanf:"h4ptix"`
+	GPIO struct {
+		Chip      string `koanf:"chip"`
+		ActiveLow bool   `koanf:"active_low"`
+		Location  string `koanf:"location"`
+		Ports     []struct {
+			Port int `koanf:"port"`
+			Line int `koanf:"line"`
+		} `koanf:"ports"`
+	} `koanf:"gpio"`
+	SerialRelay struct {
+		Device   string `koanf:"device"`
+		Baud     int    `koanf:"baud"`
+		On       string `koanf:"on"`
+		Off      string `koanf:"off"`
+		Location string `koanf:"location"`
+		Channels []struct {
+			Port    int    `koanf:"port"`
+			Channel int    `koanf:"channel"`
+			On      string `koanf:"on"`
+			Off     string `koanf:"off"`
+		} `koanf:"channels"`
+	} `koanf:"serial_relay"`
+	HIDRelay struct {
+		Serial   string `koanf:"serial"`
+		Channels []struct {
+			Port    int `koanf:"port"`
+			Channel int `koanf:"channel"`
+		} `koanf:"channels"`
+	} `koanf:"hidrelay"`
+	Plugin struct {
+		Path           string        `koanf:"path"`
+		Args           []string      `koanf:"args"`
+		Timeout        time.Duration `koanf:"timeout"`
+		HealthInterval time.Duration `koanf:"health_interval"`
+	} `koanf:"plugin"`
+	Remote struct {
+		Upstream string        `koanf:"upstream"`
+		Token    string        `koanf:"token"`
+		Toucher  string        `koanf:"toucher"`
+		Timeout  time.Duration `koanf:"timeout"`
+		Retries  int           `koanf:"retries"`
+		Location string        `koanf:"location"`
+	} `koanf:"remote"`
 }
 
-func (r *Runtime) Toucher() (touchctl.Toucher, error) {
-	if r.toucher != nil {
-		return r.toucher, nil
+func (r *Runtime) Touchers() (*touchctl.Set, error) {
+	if r.touchers != nil {
+		return r.touchers, nil
 	}
 
-	switch r.cfg.Touch.Kind {
+	cfgs := r.cfg.Touch.Touchers
+	if len(cfgs) == 0 {
+		cfgs = []ToucherCfg{r.cfg.Touch.ToucherCfg}
+	}
+
+	touchers := touchctl.NewSet()
+	for i, cfg := range cfgs {
+		if cfg.Kind == touchctl.ToucherKindNone {
+			continue
+		}
+
+		id := cfg.ID
+		if id == "" {
+			id = DefaultToucherID
+			if i > 0 {
+				id = fmt.Sprintf("toucher-%d", i)
+			}
+		}
+
+		toucher, err := cfg.NewToucher()
+		if err != nil {
+			_ = touchers.Close()
+			return nil, fmt.Errorf("initialize toucher %s: %w", id, err)
+		}
+
+		if err := touchers.Add(id, toucher); err != nil {
+			_ = touchers.Close()
+			return nil, err
+		}
+	}
+
+	r.touchers = touchers
+	return r.touchers, nil
+}
+
+func (c *ToucherCfg) NewToucher() (touchctl.Toucher, error) {
+	switch c.Kind {
 	case touchctl.ToucherKindNone:
-		r.toucher = touchctl.NewNopToucher()
-		return r.toucher, nil
+		return touchctl.NewNopToucher(), nil
 
 	case touchctl.ToucherKindH4ptix:
-		var err error
-		r.toucher, err = touchctl.NewH4ptix(
-			touchctl.H4ptixWithSerial(r.cfg.Touch.H4ptix.Serial),
+		return touchctl.NewH4ptix(
+			touchctl.H4ptixWithSerial(c.H4ptix.Serial),
+		)
+
+	case touchctl.ToucherKindGPIO:
+		opts := []touchctl.GPIOOption{
+			touchctl.GPIOWithChip(c.GPIO.Chip),
+			touchctl.GPIOWithActiveLow(c.GPIO.ActiveLow),
+			touchctl.GPIOWithLocation(c.GPIO.Location),
+		}
+		for _, p := range c.GPIO.Ports {
+			opts = append(opts, touchctl.GPIOWithLine(p.Port, p.Line))
+		}
+
+		return touchctl.NewGPIO(opts...)
+
+	case touchctl.ToucherKindSerialRelay:
+		opts := []touchctl.SerialRelayOption{
+			touchctl.SerialRelayWithDevice(c.SerialRelay.Device),
+			touchctl.SerialRelayWithBaud(c.SerialRelay.Baud),
+			touchctl.SerialRelayWithCommands(c.SerialRelay.On, c.SerialRelay.Off),
+			touchctl.SerialRelayWithLocation(c.SerialRelay.Location),
+		}
+		for _, ch := range c.SerialRelay.Channels {
+			opts = append(opts, touchctl.SerialRelayWithChannel(ch.Port, ch.Channel, ch.On, ch.Off))
+		}
+
+		return touchctl.NewSerialRelay(opts...)
+
+	case touchctl.ToucherKindHIDRelay:
+		opts := []touchctl.HIDRelayOption{
+			touchctl.HIDRelayWithSerial(c.HIDRelay.Serial),
+		}
+		for _, ch := range c.HIDRelay.Channels {
+			opts = append(opts, touchctl.HIDRelayWithChannel(ch.Port, ch.Channel))
+		}
+
+		return touchctl.NewHIDRelay(opts...)
+
+	case touchctl.ToucherKindPlugin:
+		return touchctl.NewPlugin(
+			c.Plugin.Path,
+			touchctl.PluginWithArgs(c.Plugin.Args...),
+			touchctl.PluginWithTimeout(c.Plugin.Timeout),
+			touchctl.PluginWithHealthInterval(c.Plugin.HealthInterval),
+		)
+
+	case touchctl.ToucherKindRemote:
+		return touchctl.NewRemote(
+			c.Remote.Upstream,
+			touchctl.RemoteWithToken(c.Remote.Token),
+			touchctl.RemoteWithToucher(c.Remote.Toucher),
+			touchctl.RemoteWithTimeout(c.Remote.Timeout),
+			touchctl.RemoteWithRetries(c.Remote.Retries),
+			touchctl.RemoteWithLocation(c.Remote.Location),
 		)
-		return r.toucher, err
 
 	default:
-		return nil, fmt.Errorf("unknown touch kind %s", r.cfg.Touch.Kind)
+		return nil, fmt.Errorf("unknown touch kind %s", c.Kind)
 	}
 }
@@ -4,16 +4,47 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/buglloc/yubictld/internal/ccid"
+	"github.com/buglloc/yubictld/internal/hotplug"
+	"github.com/buglloc/yubictld/internal/simulator"
+	"github.com/buglloc/yubictld/internal/touchctl"
 	"github.com/buglloc/yubictld/internal/ykman"
 )
 
 type YkManCfg struct {
-	LockTTL   time.Duration       `koanf:"lock_ttl"`
+	LockTTL       time.Duration `koanf:"lock_ttl"`
+	MaxLockTTL    time.Duration `koanf:"max_lock_ttl"`
+	MaxLifetime   time.Duration `koanf:"max_lifetime"`
+	MissingGrace  time.Duration `koanf:"missing_grace"`
+	RebootTimeout time.Duration `koanf:"reboot_timeout"`
+	PowerOffDelay time.Duration `koanf:"power_off_delay"`
+	SysfsRoot     string        `koanf:"sysfs_root"`
+	DevRoot       string        `koanf:"dev_root"`
+	Health        struct {
+		Interval    time.Duration `koanf:"interval"`
+		MaxFailures int           `koanf:"max_failures"`
+	} `koanf:"health"`
+	Backends []ykman.BackendKind `koanf:"backends"`
+	PCSC     struct {
+		Socket string `koanf:"socket"`
+	} `koanf:"pcsc"`
 	Discovery ykman.DiscoveryKind `koanf:"discovery"`
-	Manual    struct {
+	Hotplug   bool                `koanf:"hotplug"`
+	Release   struct {
+		Policies   []ykman.ReleasePolicyKind `koanf:"policies"`
+		Timeout    time.Duration             `koanf:"timeout"`
+		RebootWait time.Duration             `koanf:"reboot_wait"`
+		YkmanPath  string                    `koanf:"ykman_path"`
+	} `koanf:"release"`
+	Leases struct {
+		Kind ykman.LeaseStoreKind `koanf:"kind"`
+		Path string               `koanf:"path"`
+	} `koanf:"leases"`
+	Manual struct {
 		Yubikeys []struct {
-			Serial uint32 `koanf:"serial"`
-			Port   int    `koanf:"port"`
+			Serial  uint32 `koanf:"serial"`
+			Toucher string `koanf:"toucher"`
+			Port    int    `koanf:"port"`
 		} `koanf:"yubikeys"`
 	} `koanf:"manual"`
 }
@@ -28,33 +59,199 @@ func (r *Runtime) YkMan() (*ykman.YkMan, error) {
 		return nil, fmt.Errorf("inialize yubikeys discovery: %w", err)
 	}
 
+	leases, err := r.NewLeaseStore()
+	if err != nil {
+		return nil, fmt.Errorf("initialize lease store: %w", err)
+	}
+
+	enumerator, err := r.Enumerator()
+	if err != nil {
+		return nil, fmt.Errorf("initialize device backend: %w", err)
+	}
+
+	policies, err := r.NewReleasePolicies()
+	if err != nil {
+		return nil, fmt.Errorf("initialize release policies: %w", err)
+	}
+
 	yk := ykman.NewYkMan(
 		ykman.WithLockTTL(r.cfg.YkMan.LockTTL),
+		ykman.WithMaxLockTTL(r.cfg.YkMan.MaxLockTTL),
+		ykman.WithMaxLifetime(r.cfg.YkMan.MaxLifetime),
+		ykman.WithMissingGrace(r.cfg.YkMan.MissingGrace),
+		ykman.WithRebootTimeout(r.cfg.YkMan.RebootTimeout),
+		ykman.WithPowerOffDelay(r.cfg.YkMan.PowerOffDelay),
+		ykman.WithSysfsRoot(r.cfg.YkMan.SysfsRoot),
+		ykman.WithDevRoot(r.cfg.YkMan.DevRoot),
+		ykman.WithHealthInterval(r.cfg.YkMan.Health.Interval),
+		ykman.WithMaxFailures(r.cfg.YkMan.Health.MaxFailures),
+		ykman.WithEnumerator(enumerator),
 		ykman.WithDiscovery(disco),
+		ykman.WithLeaseStore(leases),
+		ykman.WithReleasePolicies(policies...),
+		ykman.WithCleanupTimeout(r.cfg.YkMan.Release.Timeout),
 	)
-	return yk, yk.ReloadDevices()
+	if err := yk.ReloadDevices(); err != nil {
+		return nil, fmt.Errorf("reload devices: %w", err)
+	}
+
+	r.ykman = yk
+	return yk, nil
+}
+
+func (r *Runtime) NewHotplugMonitor() (*hotplug.Monitor, error) {
+	if !r.cfg.YkMan.Hotplug || r.IsSimulated() {
+		return nil, nil
+	}
+
+	yk, err := r.YkMan()
+	if err != nil {
+		return nil, fmt.Errorf("create ykman runtime: %w", err)
+	}
+
+	return hotplug.NewMonitor(yk)
+}
+
+func (r *Runtime) NewLeaseStore() (ykman.LeaseStore, error) {
+	switch r.cfg.YkMan.Leases.Kind {
+	case "", ykman.LeaseStoreKindMemory:
+		return ykman.NewMemoryLeaseStore(), nil
+
+	case ykman.LeaseStoreKindFile:
+		return ykman.NewFileLeaseStore(r.cfg.YkMan.Leases.Path)
+
+	default:
+		return nil, fmt.Errorf("unsupported lease store: %s", r.cfg.YkMan.Leases.Kind)
+	}
+}
+
+func (r *Runtime) NewReleasePolicies() ([]ykman.ReleasePolicy, error) {
+	cfg := r.cfg.YkMan.Release
+	out := make([]ykman.ReleasePolicy, 0, len(cfg.Policies))
+	for _, kind := range cfg.Policies {
+		switch kind {
+		case ykman.ReleasePolicyKindReboot:
+			out = append(out, ykman.NewRebootPolicy(cfg.RebootWait))
+
+		case ykman.ReleasePolicyKindFIDOReset:
+			out = append(out, ykman.NewFIDOResetPolicy(cfg.RebootWait))
+
+		case ykman.ReleasePolicyKindPIVReset:
+			out = append(out, ykman.NewPIVResetPolicy(cfg.YkmanPath))
+
+		case ykman.ReleasePolicyKindOATHReset:
+			out = append(out, ykman.NewOATHResetPolicy(cfg.YkmanPath))
+
+		case ykman.ReleasePolicyKindCCIDReset:
+			out = append(out, ykman.NewCCIDResetPolicy(cfg.RebootWait))
+
+		default:
+			return nil, fmt.Errorf("unsupported release policy: %s", kind)
+		}
+	}
+
+	return out, nil
+}
+
+const SimulatedToucherID = "sim"
+
+func (r *Runtime) Enumerator() (ykman.Enumerator, error) {
+	if r.enumerator != nil {
+		return r.enumerator, nil
+	}
+
+	backends := r.cfg.YkMan.Backends
+	if len(backends) == 0 {
+		backends = []ykman.BackendKind{ykman.BackendKindHID}
+	}
+
+	enumerators := make([]ykman.Enumerator, len(backends))
+	for i, kind := range backends {
+		switch kind {
+		case ykman.BackendKindHID:
+			enumerators[i] = ykman.NewHIDEnumerator()
+
+		case ykman.BackendKindPCSC:
+			enumerators[i] = ykman.NewCCIDEnumerator(ccid.NewPCSC(r.cfg.YkMan.PCSC.Socket))
+
+		default:
+			return nil, fmt.Errorf("unsupported device backend: %s", kind)
+		}
+	}
+
+	if len(enumerators) == 1 {
+		r.enumerator = enumerators[0]
+	} else {
+		r.enumerator = ykman.NewMergedEnumerator(enumerators...)
+	}
+
+	return r.enumerator, nil
+}
+
+// Simulate replaces the hardware with count simulated Yubikeys and a recording toucher.
+// Must be called before any other runtime component is created.
+func (r *Runtime) Simulate(count int, opts ...simulator.Option) *simulator.Enumerator {
+	enum := simulator.NewEnumerator(count, opts...)
+	r.enumerator = enum
+	r.touchers = touchctl.NewSet()
+	_ = r.touchers.Add(SimulatedToucherID, simulator.NewToucher(enum.HubLocation()))
+	return enum
+}
+
+func (r *Runtime) IsSimulated() bool {
+	_, ok := r.enumerator.(*simulator.Enumerator)
+	return ok
 }
 
 func (r *Runtime) NewDiscovery() (ykman.Discovery, error) {
+	if sim, ok := r.enumerator.(*simulator.Enumerator); ok {
+		// simulated keys are wired to the simulated toucher port by port
+		toucher, _ := r.touchers.Get(SimulatedToucherID)
+		yMap := make(map[uint32]ykman.TouchPoint)
+		for serial, port := range sim.Ports() {
+			yMap[serial] = ykman.TouchPoint{
+				ToucherID: SimulatedToucherID,
+				Toucher:   toucher,
+				Port:      port,
+			}
+		}
+
+		return ykman.NewManualDiscovery(yMap)
+	}
+
 	switch r.cfg.YkMan.Discovery {
 	case ykman.DiscoveryKindNone:
 		return nil, nil
 
 	case ykman.DiscoveryKindManual:
-		yMap := make(map[uint32]int)
+		touchers, err := r.Touchers()
+		if err != nil {
+			return nil, fmt.Errorf("initialize touchers: %w", err)
+		}
+
+		yMap := make(map[uint32]ykman.TouchPoint)
 		for _, y := range r.cfg.YkMan.Manual.Yubikeys {
-			yMap[y.Serial] = y.Port
+			toucherID, toucher, err := touchers.Lookup(y.Toucher)
+			if err != nil {
+				return nil, fmt.Errorf("yubikey %d: %w", y.Serial, err)
+			}
+
+			yMap[y.Serial] = ykman.TouchPoint{
+				ToucherID: toucherID,
+				Toucher:   toucher,
+				Port:      y.Port,
+			}
 		}
 
 		return ykman.NewManualDiscovery(yMap)
 
 	case ykman.DiscoveryKindToucher:
-		toucher, err := r.Toucher()
+		touchers, err := r.Touchers()
 		if err != nil {
-			return nil, fmt.Errorf("initialize toucher: %w", err)
+			return nil, fmt.Errorf("initialize touchers: %w", err)
 		}
 
-		return ykman.NewToucherDiscovery(toucher)
+		return ykman.NewToucherDiscovery(touchers)
 
 	default:
 		return nil, fmt.Errorf("unsupported discovery: %s", r.cfg.YkMan.Discovery)
@@ -0,0 +1,225 @@
+package ctaphid
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/buglloc/usbhid"
+)
+
+const (
+	broadcastCID uint32 = 0xFFFFFFFF
+
+	typeInit = 0x80
+
+	cmdInit      = 0x06
+	cmdCBOR      = 0x10
+	cmdCancel    = 0x11
+	cmdKeepalive = 0x3B
+	cmdError     = 0x3F
+
+	initHeaderLen = 7
+	contHeaderLen = 5
+)
+
+const (
+	AuthenticatorReset = 0x07
+)
+
+var ErrCanceled = errors.New("operation canceled")
+
+// StatusError is a non-zero CTAP2 status code returned by the authenticator.
+type StatusError struct {
+	Code byte
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ctap2 status: 0x%02x", e.Code)
+}
+
+// Conn is a minimal CTAPHID channel, enough to issue CTAP2 commands that
+// require user presence: unlike fidoctl it tolerates keepalives and supports cancellation.
+type Conn struct {
+	dev *usbhid.Device
+	cid uint32
+}
+
+func Open(path string) (*Conn, error) {
+	dev, err := usbhid.Get(
+		usbhid.WithDeviceFilterFunc(func(d *usbhid.Device) bool {
+			return d.Path() == path
+		}),
+		usbhid.WithOpen(true, true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+
+	c := &Conn{
+		dev: dev,
+		cid: broadcastCID,
+	}
+
+	if err := c.init(); err != nil {
+		_ = dev.Close()
+		return nil, fmt.Errorf("init channel: %w", err)
+	}
+
+	return c, nil
+}
+
+// CBOR sends the CTAP2 command and waits for its result, skipping keepalives.
+// When ctx is done the pending command is canceled.
+func (c *Conn) CBOR(ctx context.Context, cmd byte, params []byte) ([]byte, error) {
+	if err := c.send(cmdCBOR, append([]byte{cmd}, params...)); err != nil {
+		return nil, err
+	}
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = c.send(cmdCancel, nil)
+	})
+	defer stop()
+
+	for {
+		rspCmd, data, err := c.recv()
+		if err != nil {
+			return nil, err
+		}
+
+		switch rspCmd {
+		case cmdKeepalive:
+			continue
+		case cmdError:
+			return nil, fmt.Errorf("ctaphid error: 0x%x", data)
+		case cmdCBOR:
+		default:
+			return nil, fmt.Errorf("unexpected command response: 0x%02x", rspCmd)
+		}
+
+		if len(data) == 0 {
+			return nil, errors.New("empty response")
+		}
+
+		if data[0] != 0 {
+			if ctx.Err() != nil {
+				return nil, fmt.Errorf("%w: %w", ErrCanceled, ctx.Err())
+			}
+
+			return nil, &StatusError{Code: data[0]}
+		}
+
+		return data[1:], nil
+	}
+}
+
+// Reset performs authenticatorReset. The authenticator accepts it only shortly after
+// power up and only once the user touched it.
+func (c *Conn) Reset(ctx context.Context) error {
+	_, err := c.CBOR(ctx, AuthenticatorReset, nil)
+	return err
+}
+
+func (c *Conn) Close() error {
+	return c.dev.Close()
+}
+
+func (c *Conn) init() error {
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("generate nonce: %w", err)
+	}
+
+	if err := c.send(cmdInit, nonce); err != nil {
+		return err
+	}
+
+	for {
+		rspCmd, data, err := c.recv()
+		if err != nil {
+			return err
+		}
+
+		if rspCmd != cmdInit || len(data) < 17 || !bytes.Equal(data[:8], nonce) {
+			// response to somebody else's INIT on the broadcast channel
+			continue
+		}
+
+		c.cid = binary.BigEndian.Uint32(data[8:12])
+		return nil
+	}
+}
+
+func (c *Conn) send(cmd byte, data []byte) error {
+	reportLen := int(c.dev.GetOutputReportLength())
+	report := make([]byte, 0, reportLen)
+	report = binary.BigEndian.AppendUint32(report, c.cid)
+	report = append(report, cmd|typeInit, byte(len(data)>>8), byte(len(data)))
+
+	n := min(len(data), reportLen-initHeaderLen)
+	report = append(report, data[:n]...)
+	data = data[n:]
+	if err := c.dev.SetOutputReport(0, report); err != nil {
+		return fmt.Errorf("send init report: %w", err)
+	}
+
+	for seq := byte(0); len(data) > 0; seq++ {
+		report = report[:0]
+		report = binary.BigEndian.AppendUint32(report, c.cid)
+		report = append(report, seq)
+
+		n := min(len(data), reportLen-contHeaderLen)
+		report = append(report, data[:n]...)
+		data = data[n:]
+		if err := c.dev.SetOutputReport(0, report); err != nil {
+			return fmt.Errorf("send cont report[%d]: %w", seq, err)
+		}
+	}
+
+	return nil
+}
+
+func (c *Conn) recv() (byte, []byte, error) {
+	var (
+		cmd  byte
+		size int
+		out  []byte
+		seq  byte
+	)
+
+	for {
+		_, report, err := c.dev.GetInputReport()
+		if err != nil {
+			return 0, nil, fmt.Errorf("read report: %w", err)
+		}
+
+		if len(report) < contHeaderLen || binary.BigEndian.Uint32(report) != c.cid {
+			continue
+		}
+
+		if out == nil {
+			if len(report) < initHeaderLen || report[4]&typeInit == 0 {
+				continue
+			}
+
+			cmd = report[4] &^ typeInit
+			size = int(report[5])<<8 | int(report[6])
+			out = make([]byte, 0, size)
+			out = append(out, report[initHeaderLen:initHeaderLen+min(size, len(report)-initHeaderLen)]...)
+		} else {
+			if report[4] != seq {
+				return 0, nil, fmt.Errorf("unexpected sequence: %d (got) != %d (expected)", report[4], seq)
+			}
+
+			seq++
+			out = append(out, report[contHeaderLen:contHeaderLen+min(size-len(out), len(report)-contHeaderLen)]...)
+		}
+
+		if len(out) >= size {
+			return cmd, out, nil
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package hotplug
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+const (
+	subsystemHIDRaw = "hidraw"
+	subsystemUSB    = "usb"
+	devTypeUSB      = "usb_device"
+	// uevent PRODUCT of Yubico devices, in the "vid/pid/bcdDevice" format
+	productYubico = "1050/"
+)
+
+type EventKind string
+
+const (
+	EventKindAdded   EventKind = "added"
+	EventKindRemoved EventKind = "removed"
+)
+
+type Event struct {
+	Kind   EventKind
+	Serial uint32
+	Path   string
+	Time   time.Time
+}
+
+// Monitor tracks hidraw and USB hot-plug events and keeps the YkMan pool in sync.
+// hidraw events attach and detach single devices, while USB events of Yubico devices
+// trigger a resync, so keys exposing only the CCID interface are tracked as well.
+type Monitor struct {
+	yk          *ykman.YkMan
+	src         UEventSource
+	settleDelay time.Duration
+	addAttempts int
+	handlers    []func(Event)
+	log         zerolog.Logger
+	wg          sync.WaitGroup
+	mu          sync.Mutex
+	adding      map[string]*pendingAdd
+	syncMu      sync.Mutex
+}
+
+type pendingAdd struct {
+	cancel context.CancelFunc
+}
+
+func NewMonitor(yk *ykman.YkMan, opts ...Option) (*Monitor, error) {
+	m := &Monitor{
+		yk:          yk,
+		adding:      make(map[string]*pendingAdd),
+		settleDelay: DefaultSettleDelay,
+		addAttempts: DefaultAddAttempts,
+		log: log.With().
+			Str("source", "hotplug").
+			Logger(),
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	if m.src == nil {
+		src, err := NewNetlinkSource()
+		if err != nil {
+			return nil, fmt.Errorf("create uevent source: %w", err)
+		}
+
+		m.src = src
+	}
+
+	return m, nil
+}
+
+// Run processes events until ctx is done or the source fails.
+// Malformed events are skipped and lost ones are recovered by a resync.
+func (m *Monitor) Run(ctx context.Context) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer func() {
+		cancel()
+		m.wg.Wait()
+	}()
+
+	stop := context.AfterFunc(ctx, func() {
+		_ = m.src.Close()
+	})
+	defer stop()
+
+	for {
+		ev, err := m.src.Read()
+		switch {
+		case err == nil:
+		case ctx.Err() != nil:
+			return nil
+
+		case errors.Is(err, ErrInvalidUEvent):
+			m.log.Warn().Err(err).Msg("skip malformed uevent")
+			continue
+
+		case errors.Is(err, ErrEventsLost):
+			m.log.Warn().Err(err).Msg("uevents lost, resyncing")
+			m.spawn(func() {
+				m.resync(ctx, 1)
+			})
+			continue
+
+		default:
+			return err
+		}
+
+		switch {
+		case ev.Subsystem == subsystemHIDRaw && ev.DevName != "":
+			devPath := path.Join("/dev", ev.DevName)
+			switch ev.Action {
+			case ActionAdd:
+				m.startAdd(ctx, devPath)
+			case ActionRemove:
+				m.cancelAdd(devPath)
+				m.handleRemove(devPath)
+			}
+
+		case ev.Subsystem == subsystemUSB && ev.Env["DEVTYPE"] == devTypeUSB && strings.HasPrefix(ev.Env["PRODUCT"], productYubico):
+			switch ev.Action {
+			case ActionAdd:
+				m.spawn(func() {
+					m.resync(ctx, m.addAttempts)
+				})
+			case ActionRemove:
+				m.spawn(func() {
+					m.resync(ctx, 1)
+				})
+			}
+		}
+	}
+}
+
+func (m *Monitor) Close() error {
+	return m.src.Close()
+}
+
+func (m *Monitor) spawn(fn func()) {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		fn()
+	}()
+}
+
+// startAdd attaches the device in background, so its settle retries don't hold back other events.
+func (m *Monitor) startAdd(ctx context.Context, devPath string) {
+	ctx, cancel := context.WithCancel(ctx)
+	pending := &pendingAdd{cancel: cancel}
+
+	m.mu.Lock()
+	if prev, ok := m.adding[devPath]; ok {
+		prev.cancel()
+	}
+	m.adding[devPath] = pending
+	m.mu.Unlock()
+
+	m.spawn(func() {
+		defer func() {
+			cancel()
+
+			// a newer add of the same path may have taken the slot over
+			m.mu.Lock()
+			if m.adding[devPath] == pending {
+				delete(m.adding, devPath)
+			}
+			m.mu.Unlock()
+		}()
+
+		m.handleAdd(ctx, devPath)
+	})
+}
+
+// cancelAdd stops a pending attach of the removed device.
+func (m *Monitor) cancelAdd(devPath string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if pending, ok := m.adding[devPath]; ok {
+		pending.cancel()
+		delete(m.adding, devPath)
+	}
+}
+
+// resync re-enumerates the devices and reports what changed, retrying up to attempts times
+// until something does, e.g. when pcscd is yet to notice a freshly plugged reader.
+func (m *Monitor) resync(ctx context.Context, attempts int) {
+	for attempt := 0; attempt < attempts; attempt++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.settleDelay):
+		}
+
+		if m.syncOnce() {
+			return
+		}
+	}
+}
+
+func (m *Monitor) syncOnce() bool {
+	m.syncMu.Lock()
+	defer m.syncMu.Unlock()
+
+	before := m.present()
+	if err := m.yk.ReloadDevices(); err != nil {
+		m.log.Error().Err(err).Msg("resync failed")
+		return false
+	}
+
+	after := m.present()
+	changed := false
+	for serial, devPath := range after {
+		if _, ok := before[serial]; !ok {
+			changed = true
+			m.log.Info().
+				Str("path", devPath).
+				Uint32("yk_serial", serial).
+				Msg("yubikey attached")
+
+			m.emit(Event{
+				Kind:   EventKindAdded,
+				Serial: serial,
+				Path:   devPath,
+			})
+		}
+	}
+
+	for serial, devPath := range before {
+		if _, ok := after[serial]; !ok {
+			changed = true
+			m.log.Info().
+				Str("path", devPath).
+				Uint32("yk_serial", serial).
+				Msg("yubikey detached")
+
+			m.emit(Event{
+				Kind:   EventKindRemoved,
+				Serial: serial,
+				Path:   devPath,
+			})
+		}
+	}
+
+	return changed
+}
+
+// present returns paths of the pooled Yubikeys which are currently plugged in, by serial.
+func (m *Monitor) present() map[uint32]string {
+	out := make(map[uint32]string)
+	for _, yk := range m.yk.Devices() {
+		if yk.IsMissing() {
+			continue
+		}
+
+		out[yk.Serial()] = yk.Path()
+	}
+
+	return out
+}
+
+func (m *Monitor) handleAdd(ctx context.Context, devPath string) {
+	var lastErr error
+	for attempt := 0; attempt < m.addAttempts; attempt++ {
+		// give udev a chance to apply permissions and the device to finish its init
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(m.settleDelay):
+		}
+
+		yk, fresh, err := m.attach(devPath)
+		switch {
+		case err == nil && !fresh:
+			return
+
+		case err == nil:
+			m.log.Info().
+				Str("path", devPath).
+				Uint32("yk_serial", yk.Serial()).
+				Str("toucher", yk.ToucherID()).
+				Int("port", yk.Port()).
+				Msg("yubikey attached")
+
+			m.emit(Event{
+				Kind:   EventKindAdded,
+				Serial: yk.Serial(),
+				Path:   devPath,
+			})
+			return
+
+		case errors.Is(err, ykman.ErrNotYubikey), errors.Is(err, ykman.ErrUnmanaged):
+			m.log.Debug().
+				Str("path", devPath).
+				Err(err).
+				Msg("skip device")
+			return
+		}
+
+		lastErr = err
+	}
+
+	m.log.Error().
+		Str("path", devPath).
+		Err(lastErr).
+		Msg("unable to attach device")
+}
+
+// attach adds the device to the pool, reporting whether its Yubikey was not seen plugged in yet,
+// e.g. by a resync triggered for the same plug.
+func (m *Monitor) attach(devPath string) (*ykman.Yubikey, bool, error) {
+	m.syncMu.Lock()
+	defer m.syncMu.Unlock()
+
+	before := m.present()
+	yk, err := m.yk.AddDevice(devPath)
+	if err != nil {
+		return nil, false, err
+	}
+
+	_, known := before[yk.Serial()]
+	return yk, !known, nil
+}
+
+func (m *Monitor) handleRemove(devPath string) {
+	m.syncMu.Lock()
+	defer m.syncMu.Unlock()
+
+	before := m.present()
+	yk, ok := m.yk.RemoveDevice(devPath)
+	if !ok {
+		return
+	}
+
+	if _, known := before[yk.Serial()]; !known {
+		return
+	}
+
+	m.log.Info().
+		Str("path", devPath).
+		Uint32("yk_serial", yk.Serial()).
+		Msg("yubikey detached")
+
+	m.emit(Event{
+		Kind:   EventKindRemoved,
+		Serial: yk.Serial(),
+		Path:   devPath,
+	})
+}
+
+func (m *Monitor) emit(ev Event) {
+	ev.Time = time.Now()
+	for _, h := range m.handlers {
+		h(ev)
+	}
+}
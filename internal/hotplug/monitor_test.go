@@ -0,0 +1,263 @@
+package hotplug_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/hotplug"
+	"github.com/buglloc/yubictld/internal/simulator"
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+type fakeSource struct {
+	events chan any
+	closed chan struct{}
+}
+
+func newFakeSource() *fakeSource {
+	return &fakeSource{
+		events: make(chan any, 16),
+		closed: make(chan struct{}),
+	}
+}
+
+func (s *fakeSource) Read() (hotplug.UEvent, error) {
+	select {
+	case <-s.closed:
+		return hotplug.UEvent{}, errors.New("closed")
+	case ev := <-s.events:
+		switch v := ev.(type) {
+		case error:
+			return hotplug.UEvent{}, v
+		case hotplug.UEvent:
+			return v, nil
+		default:
+			panic(fmt.Sprintf("unexpected event: %T", ev))
+		}
+	}
+}
+
+func (s *fakeSource) Close() error {
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+	return nil
+}
+
+func hidrawEvent(action string, devPath string) hotplug.UEvent {
+	return hotplug.UEvent{
+		Action:    action,
+		Subsystem: "hidraw",
+		DevName:   path.Base(devPath),
+	}
+}
+
+func usbEvent(action string) hotplug.UEvent {
+	return hotplug.UEvent{
+		Action:    action,
+		Subsystem: "usb",
+		Env: map[string]string{
+			"DEVTYPE": "usb_device",
+			"PRODUCT": "1050/407/543",
+		},
+	}
+}
+
+type env struct {
+	sim    *simulator.Enumerator
+	yk     *ykman.YkMan
+	src    *fakeSource
+	events chan hotplug.Event
+}
+
+func newEnv(t *testing.T) *env {
+	return newEnvWithSettle(t, time.Millisecond)
+}
+
+func newEnvWithSettle(t *testing.T, settleDelay time.Duration) *env {
+	t.Helper()
+
+	sim := simulator.NewEnumerator(2, simulator.WithRebootLatency(0))
+	yk := ykman.NewYkMan(
+		ykman.WithEnumerator(sim),
+		ykman.WithReapInterval(0),
+	)
+	t.Cleanup(func() {
+		_ = yk.Close()
+	})
+	require.NoError(t, yk.ReloadDevices())
+
+	e := &env{
+		sim:    sim,
+		yk:     yk,
+		src:    newFakeSource(),
+		events: make(chan hotplug.Event, 16),
+	}
+
+	monitor, err := hotplug.NewMonitor(yk,
+		hotplug.WithSource(e.src),
+		hotplug.WithSettleDelay(settleDelay),
+		hotplug.WithAddAttempts(3),
+		hotplug.WithHandler(func(ev hotplug.Event) {
+			e.events <- ev
+		}),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- monitor.Run(ctx)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+
+	return e
+}
+
+func (e *env) next(t *testing.T) hotplug.Event {
+	t.Helper()
+
+	select {
+	case ev := <-e.events:
+		return ev
+	case <-time.After(5 * time.Second):
+		t.Fatal("no hotplug event")
+		return hotplug.Event{}
+	}
+}
+
+func (e *env) noEvent(t *testing.T) {
+	t.Helper()
+
+	select {
+	case ev := <-e.events:
+		t.Fatalf("unexpected hotplug event: %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func present(yk *ykman.YkMan, serial uint32) bool {
+	for _, dev := range yk.Devices() {
+		if dev.Serial() == serial {
+			return !dev.IsMissing()
+		}
+	}
+
+	return false
+}
+
+func TestMonitor_HIDRawRemoveAdd(t *testing.T) {
+	e := newEnv(t)
+	dev := e.sim.Devices()[0]
+
+	oldPath := dev.Path()
+	dev.Unplug()
+	e.src.events <- hidrawEvent(hotplug.ActionRemove, oldPath)
+
+	ev := e.next(t)
+	require.Equal(t, hotplug.EventKindRemoved, ev.Kind)
+	require.Equal(t, dev.Serial(), ev.Serial)
+	require.False(t, present(e.yk, dev.Serial()))
+
+	dev.Plug()
+	newPath := dev.Path()
+	require.NotEqual(t, oldPath, newPath)
+	e.src.events <- hidrawEvent(hotplug.ActionAdd, newPath)
+
+	ev = e.next(t)
+	require.Equal(t, hotplug.EventKindAdded, ev.Kind)
+	require.Equal(t, dev.Serial(), ev.Serial)
+	require.Equal(t, newPath, ev.Path)
+	require.True(t, present(e.yk, dev.Serial()))
+}
+
+func TestMonitor_RemoveCancelsPendingAdd(t *testing.T) {
+	e := newEnvWithSettle(t, 50*time.Millisecond)
+	dev := e.sim.Devices()[0]
+
+	dev.Unplug()
+	e.src.events <- hidrawEvent(hotplug.ActionRemove, dev.Path())
+	require.Equal(t, hotplug.EventKindRemoved, e.next(t).Kind)
+
+	// the add keeps retrying in background while the device is not ready
+	dev.Fail(simulator.OpInfo, errors.New("not ready"))
+	dev.Plug()
+	e.src.events <- hidrawEvent(hotplug.ActionAdd, dev.Path())
+
+	// the remove is handled right away and stops the retries, which would succeed from now on
+	e.src.events <- hidrawEvent(hotplug.ActionRemove, dev.Path())
+	time.Sleep(10 * time.Millisecond)
+	dev.Fail(simulator.OpInfo, nil)
+
+	select {
+	case ev := <-e.events:
+		t.Fatalf("unexpected hotplug event: %+v", ev)
+	case <-time.After(300 * time.Millisecond):
+	}
+	require.False(t, present(e.yk, dev.Serial()))
+}
+
+func TestMonitor_SkipsMalformedEvents(t *testing.T) {
+	e := newEnv(t)
+	dev := e.sim.Devices()[1]
+
+	_, err := hotplug.ParseUEvent([]byte("garbage\x00"))
+	require.ErrorIs(t, err, hotplug.ErrInvalidUEvent)
+	e.src.events <- err
+
+	dev.Unplug()
+	e.src.events <- hidrawEvent(hotplug.ActionRemove, dev.Path())
+
+	ev := e.next(t)
+	require.Equal(t, hotplug.EventKindRemoved, ev.Kind)
+	require.Equal(t, dev.Serial(), ev.Serial)
+}
+
+func TestMonitor_ResyncsLostEvents(t *testing.T) {
+	e := newEnv(t)
+	dev := e.sim.Devices()[0]
+
+	dev.Unplug()
+	e.src.events <- fmt.Errorf("read uevent: %w", hotplug.ErrEventsLost)
+
+	ev := e.next(t)
+	require.Equal(t, hotplug.EventKindRemoved, ev.Kind)
+	require.Equal(t, dev.Serial(), ev.Serial)
+	require.False(t, present(e.yk, dev.Serial()))
+}
+
+func TestMonitor_USBEventsResync(t *testing.T) {
+	e := newEnv(t)
+	dev := e.sim.Devices()[1]
+
+	// a key exposing only CCID has no hidraw node, so it is tracked by USB events
+	dev.Unplug()
+	e.src.events <- usbEvent(hotplug.ActionRemove)
+
+	ev := e.next(t)
+	require.Equal(t, hotplug.EventKindRemoved, ev.Kind)
+	require.Equal(t, dev.Serial(), ev.Serial)
+
+	dev.Plug()
+	e.src.events <- usbEvent(hotplug.ActionAdd)
+
+	ev = e.next(t)
+	require.Equal(t, hotplug.EventKindAdded, ev.Kind)
+	require.Equal(t, dev.Serial(), ev.Serial)
+	require.True(t, present(e.yk, dev.Serial()))
+
+	// hidraw event of the same plug is not reported twice
+	e.src.events <- hidrawEvent(hotplug.ActionAdd, dev.Path())
+	e.noEvent(t)
+}
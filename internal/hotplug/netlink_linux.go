@@ -0,0 +1,62 @@
+package hotplug
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+)
+
+const (
+	netlinkKernelGroup = 1
+	uEventBufferSize   = 16 * 1024
+)
+
+var _ UEventSource = (*NetlinkSource)(nil)
+
+type NetlinkSource struct {
+	f   *os.File
+	buf []byte
+}
+
+func NewNetlinkSource() (*NetlinkSource, error) {
+	fd, err := syscall.Socket(
+		syscall.AF_NETLINK,
+		syscall.SOCK_RAW|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK,
+		syscall.NETLINK_KOBJECT_UEVENT,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("create netlink socket: %w", err)
+	}
+
+	err = syscall.Bind(fd, &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK,
+		Groups: netlinkKernelGroup,
+	})
+	if err != nil {
+		_ = syscall.Close(fd)
+		return nil, fmt.Errorf("bind netlink socket: %w", err)
+	}
+
+	return &NetlinkSource{
+		f:   os.NewFile(uintptr(fd), "uevent"),
+		buf: make([]byte, uEventBufferSize),
+	}, nil
+}
+
+func (s *NetlinkSource) Read() (UEvent, error) {
+	n, err := s.f.Read(s.buf)
+	if err != nil {
+		if errors.Is(err, syscall.ENOBUFS) {
+			return UEvent{}, fmt.Errorf("read uevent: %w: %w", ErrEventsLost, err)
+		}
+
+		return UEvent{}, fmt.Errorf("read uevent: %w", err)
+	}
+
+	return ParseUEvent(s.buf[:n])
+}
+
+func (s *NetlinkSource) Close() error {
+	return s.f.Close()
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package hotplug
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func NewNetlinkSource() (UEventSource, error) {
+	return nil, fmt.Errorf("hotplug monitoring is not supported on %s so far", runtime.GOOS)
+}
@@ -0,0 +1,35 @@
+package hotplug
+
+import "time"
+
+const (
+	DefaultSettleDelay = 500 * time.Millisecond
+	DefaultAddAttempts = 5
+)
+
+type Option func(*Monitor)
+
+func WithSource(src UEventSource) Option {
+	return func(m *Monitor) {
+		m.src = src
+	}
+}
+
+func WithSettleDelay(d time.Duration) Option {
+	return func(m *Monitor) {
+		m.settleDelay = d
+	}
+}
+
+func WithAddAttempts(n int) Option {
+	return func(m *Monitor) {
+		m.addAttempts = n
+	}
+}
+
+// WithHandler registers a callback for pool changes, it may be called from several goroutines.
+func WithHandler(fn func(Event)) Option {
+	return func(m *Monitor) {
+		m.handlers = append(m.handlers, fn)
+	}
+}
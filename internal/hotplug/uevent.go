@@ -0,0 +1,63 @@
+package hotplug
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+var ErrInvalidUEvent = errors.New("invalid uevent")
+
+// ErrEventsLost is returned by a source whose buffer overflowed, so the events must be resynced.
+var ErrEventsLost = errors.New("uevents lost")
+
+const (
+	ActionAdd    = "add"
+	ActionRemove = "remove"
+)
+
+type UEvent struct {
+	Action    string
+	DevPath   string
+	Subsystem string
+	DevName   string
+	Env       map[string]string
+}
+
+// UEventSource provides kernel uevents. Read blocks until the next event arrives
+// or the source is closed.
+type UEventSource interface {
+	Read() (UEvent, error)
+	Close() error
+}
+
+// ParseUEvent parses a kernel uevent message in the "ACTION@DEVPATH\0KEY=VALUE\0..." format.
+func ParseUEvent(msg []byte) (UEvent, error) {
+	fields := bytes.Split(bytes.TrimRight(msg, "\x00"), []byte{0})
+	if len(fields) == 0 || !bytes.ContainsRune(fields[0], '@') {
+		return UEvent{}, fmt.Errorf("%w: bad header %q", ErrInvalidUEvent, fields[0])
+	}
+
+	ev := UEvent{
+		Env: make(map[string]string, len(fields)-1),
+	}
+	for _, field := range fields[1:] {
+		key, val, ok := strings.Cut(string(field), "=")
+		if !ok {
+			continue
+		}
+
+		ev.Env[key] = val
+	}
+
+	ev.Action = ev.Env["ACTION"]
+	ev.DevPath = ev.Env["DEVPATH"]
+	ev.Subsystem = ev.Env["SUBSYSTEM"]
+	ev.DevName = ev.Env["DEVNAME"]
+	if ev.Action == "" {
+		ev.Action, ev.DevPath, _ = strings.Cut(string(fields[0]), "@")
+	}
+
+	return ev, nil
+}
@@ -0,0 +1,253 @@
+package httpd
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+
+	"github.com/buglloc/yubictld/internal/touchctl"
+	"github.com/buglloc/yubictld/internal/ykman"
+	"github.com/buglloc/yubictld/pkg/yubictl"
+)
+
+// portTouchRetention is how long the result of a keyed raw touch is kept for the request retries.
+const portTouchRetention = 10 * time.Minute
+
+type keyedTouch struct {
+	done       chan struct{}
+	err        error
+	finishedAt time.Time
+}
+
+func (s *Server) initAdmin(router fiber.Router) {
+	router.Use(func(c *fiber.Ctx) error {
+		if !s.isAdmin(c) {
+			return &fiber.Error{
+				Code:    fiber.StatusUnauthorized,
+				Message: "invalid admin token",
+			}
+		}
+
+		return c.Next()
+	})
+
+	// a touch-only host has no Yubikeys, but still serves raw touches
+	requireYkMan := func(c *fiber.Ctx) error {
+		if s.yk == nil {
+			return &fiber.Error{
+				Code:    fiber.StatusBadRequest,
+				Message: "ykman not initialized",
+			}
+		}
+
+		return c.Next()
+	}
+
+	router.Get("/yubikeys", requireYkMan, func(c *fiber.Ctx) error {
+		devices := s.yk.Devices()
+		out := yubictl.YubikeysRsp{
+			Yubikeys: make([]yubictl.YubikeyStatus, len(devices)),
+		}
+		for i, yk := range devices {
+			out.Yubikeys[i] = yubikeyStatus(yk)
+		}
+
+		return c.JSON(out)
+	})
+
+	router.Post("/quarantine", requireYkMan, func(c *fiber.Ctx) error {
+		var req yubictl.QuarantineReq
+		if err := c.BodyParser(&req); err != nil {
+			return fmt.Errorf("parse body: %w", err)
+		}
+
+		if req.Reason == "" {
+			req.Reason = "manual"
+		}
+
+		yk, err := s.yk.Quarantine(req.Serial, req.Reason)
+		if err != nil {
+			return adminError(err)
+		}
+
+		s.log.Info().
+			Uint32("yk_serial", yk.Serial()).
+			Str("reason", req.Reason).
+			Msg("quarantine yubikey")
+
+		return c.JSON(yubikeyStatus(yk))
+	})
+
+	router.Post("/unquarantine", requireYkMan, func(c *fiber.Ctx) error {
+		var req yubictl.UnquarantineReq
+		if err := c.BodyParser(&req); err != nil {
+			return fmt.Errorf("parse body: %w", err)
+		}
+
+		yk, err := s.yk.Unquarantine(req.Serial)
+		if err != nil {
+			return adminError(err)
+		}
+
+		s.log.Info().
+			Uint32("yk_serial", yk.Serial()).
+			Msg("unquarantine yubikey")
+
+		return c.JSON(yubikeyStatus(yk))
+	})
+
+	router.Post("/touch", func(c *fiber.Ctx) error {
+		var req yubictl.PortTouchReq
+		if err := c.BodyParser(&req); err != nil {
+			return fmt.Errorf("parse body: %w", err)
+		}
+
+		if s.touchers == nil {
+			return &fiber.Error{
+				Code:    fiber.StatusBadRequest,
+				Message: "touchctl not initialized",
+			}
+		}
+
+		toucherID, touch, err := s.touchers.Lookup(req.Toucher)
+		if err != nil {
+			return &fiber.Error{
+				Code:    fiber.StatusBadRequest,
+				Message: err.Error(),
+			}
+		}
+
+		if req.Port <= 0 {
+			return &fiber.Error{
+				Code:    fiber.StatusBadRequest,
+				Message: fmt.Sprintf("invalid port: %d", req.Port),
+			}
+		}
+
+		gesture, err := touchGesture(req.Delay, req.Duration, req.Preset, req.Steps)
+		if err != nil {
+			return err
+		}
+
+		timeout := gesture.Duration() + touchSlack
+		ctx, cancel := s.requestContext(c, timeout)
+		defer cancel()
+
+		err = s.portTouch(ctx, utils.CopyString(c.Get(yubictl.HeaderIdempotencyKey)), timeout, func(ctx context.Context) error {
+			return touchctl.Perform(ctx, touch, req.Port, gesture)
+		})
+		if err != nil {
+			s.log.Error().
+				Err(err).
+				Str("toucher", toucherID).
+				Int("port", req.Port).
+				Msg("raw touch failed")
+			return err
+		}
+
+		s.log.Info().
+			Str("toucher", toucherID).
+			Int("port", req.Port).
+			Msg("raw touch port")
+
+		return nil
+	})
+}
+
+// portTouch performs the raw touch once per idempotency key: a retried request waits for the touch
+// started by the first one and gets its result. Keyed touches outlive the request, as its retry takes over.
+func (s *Server) portTouch(ctx context.Context, key string, timeout time.Duration, touch func(ctx context.Context) error) error {
+	if key == "" {
+		return touch(ctx)
+	}
+
+	s.portTouchesMu.Lock()
+	for k, t := range s.portTouches {
+		select {
+		case <-t.done:
+			if time.Since(t.finishedAt) >= portTouchRetention {
+				delete(s.portTouches, k)
+			}
+		default:
+		}
+	}
+
+	t, ok := s.portTouches[key]
+	if !ok {
+		t = &keyedTouch{
+			done: make(chan struct{}),
+		}
+		s.portTouches[key] = t
+
+		go func() {
+			touchCtx, cancel := context.WithTimeout(context.Background(), timeout)
+			defer cancel()
+
+			err := touch(touchCtx)
+			s.portTouchesMu.Lock()
+			t.err = err
+			t.finishedAt = time.Now()
+			s.portTouchesMu.Unlock()
+			close(t.done)
+		}()
+	}
+	s.portTouchesMu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.done:
+		return t.err
+	}
+}
+
+func (s *Server) isAdmin(c *fiber.Ctx) bool {
+	token, ok := strings.CutPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.adminToken)) == 1
+}
+
+func yubikeyStatus(yk *ykman.Yubikey) yubictl.YubikeyStatus {
+	lease := yk.Lease()
+	quarantine := yk.Quarantine()
+	out := yubictl.YubikeyStatus{
+		Serial:           yk.Serial(),
+		Vendor:           yk.Vendor(),
+		Model:            yk.Model(),
+		Version:          yk.Version().String(),
+		Path:             yk.Path(),
+		Location:         yk.Location(),
+		Toucher:          yk.ToucherID(),
+		Port:             yk.Port(),
+		Leased:           lease.ClientID != "",
+		MissingSince:     yk.MissingSince(),
+		Cleaning:         yk.IsCleaning(),
+		Failures:         yk.Failures(),
+		Quarantined:      !quarantine.IsZero(),
+		QuarantineReason: quarantine.Reason,
+		QuarantinedAt:    quarantine.Since,
+	}
+
+	if out.Leased {
+		out.ExpiresAt = lease.ExpiresAt()
+	}
+
+	return out
+}
+
+func adminError(err error) error {
+	if errors.Is(err, ykman.ErrUnknownYubikey) {
+		return &yubictl.ServiceError{
+			HttpCode: fiber.StatusNotFound,
+			Code:     yubictl.ServiceErrorUnknownYubikey,
+			Msg:      err.Error(),
+		}
+	}
+
+	return err
+}
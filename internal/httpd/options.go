@@ -13,9 +13,9 @@ func WithAddr(addr string) Option {
 	}
 }
 
-func WithToucher(t touchctl.Toucher) Option {
+func WithTouchers(t *touchctl.Set) Option {
 	return func(s *Server) {
-		s.touch = t
+		s.touchers = t
 	}
 }
 
@@ -24,3 +24,10 @@ func WithYkMan(yk *ykman.YkMan) Option {
 		s.yk = yk
 	}
 }
+
+// WithAdminToken enables the admin API, which requires the token as a bearer token.
+func WithAdminToken(token string) Option {
+	return func(s *Server) {
+		s.adminToken = token
+	}
+}
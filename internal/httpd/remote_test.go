@@ -0,0 +1,177 @@
+package httpd_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/httpd"
+	"github.com/buglloc/yubictld/internal/simulator"
+	"github.com/buglloc/yubictld/internal/touchctl"
+	"github.com/buglloc/yubictld/internal/ykman"
+	"github.com/buglloc/yubictld/pkg/yubictl"
+)
+
+const adminToken = "s3cr3t"
+
+// serve runs the server on a random local port until the test ends, returning its address.
+func serve(t *testing.T, opts ...httpd.Option) string {
+	t.Helper()
+
+	srv, err := httpd.NewServer(opts...)
+	require.NoError(t, err)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_ = srv.Serve(ln)
+	}()
+	t.Cleanup(func() {
+		_ = srv.Shutdown(context.Background())
+		<-done
+	})
+
+	return ln.Addr().String()
+}
+
+// serveTouchHost runs a yubictld without Yubikeys, which only owns the touch rig.
+func serveTouchHost(t *testing.T) (string, *simulator.Toucher) {
+	t.Helper()
+
+	rig := simulator.NewToucher("2-1")
+	touchers := touchctl.NewSet()
+	require.NoError(t, touchers.Add("rig", rig))
+
+	addr := serve(t,
+		httpd.WithAdminToken(adminToken),
+		httpd.WithTouchers(touchers),
+	)
+	return addr, rig
+}
+
+func TestRemoteToucher_TwoServers(t *testing.T) {
+	touchAddr, rig := serveTouchHost(t)
+
+	remote, err := touchctl.NewRemote(touchAddr,
+		touchctl.RemoteWithToken(adminToken),
+		touchctl.RemoteWithToucher("rig"),
+	)
+	require.NoError(t, err)
+
+	sim := simulator.NewEnumerator(1)
+	serial := sim.Devices()[0].Serial()
+	discovery, err := ykman.NewManualDiscovery(map[uint32]ykman.TouchPoint{
+		serial: {ToucherID: "remote", Toucher: remote, Port: 3},
+	})
+	require.NoError(t, err)
+
+	yk := ykman.NewYkMan(
+		ykman.WithEnumerator(sim),
+		ykman.WithDiscovery(discovery),
+		ykman.WithReapInterval(0),
+	)
+	t.Cleanup(func() {
+		_ = yk.Close()
+	})
+	require.NoError(t, yk.ReloadDevices())
+
+	keysAddr := serve(t, httpd.WithYkMan(yk))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client := yubictl.NewSvcClient("http://" + keysAddr)
+	key, err := client.Acquire(ctx)
+	require.NoError(t, err)
+	defer func() { _ = key.Close(ctx) }()
+
+	require.NoError(t, key.Touch(ctx, yubictl.TouchWithDuration(100*time.Millisecond)))
+
+	touches := rig.Touches()
+	require.Len(t, touches, 1)
+	require.Equal(t, 3, touches[0].Port)
+	require.Equal(t, 100*time.Millisecond, touches[0].Duration)
+}
+
+func TestRemoteToucher_InvalidToken(t *testing.T) {
+	touchAddr, rig := serveTouchHost(t)
+
+	remote, err := touchctl.NewRemote(touchAddr,
+		touchctl.RemoteWithToken("wrong"),
+		touchctl.RemoteWithRetries(-1),
+	)
+	require.NoError(t, err)
+
+	err = remote.Touch(1, 0, 10*time.Millisecond)
+	require.Error(t, err)
+	require.Zero(t, rig.Attempts())
+}
+
+func TestRemoteToucher_RetryTouchesOnce(t *testing.T) {
+	touchAddr, rig := serveTouchHost(t)
+	rig.Fail(errors.New("solenoid jammed"))
+
+	// the failure is a 500, which the client retries
+	remote, err := touchctl.NewRemote(touchAddr,
+		touchctl.RemoteWithToken(adminToken),
+		touchctl.RemoteWithRetries(1),
+	)
+	require.NoError(t, err)
+
+	err = remote.Touch(1, 0, 10*time.Millisecond)
+	require.ErrorContains(t, err, "solenoid jammed")
+	require.Equal(t, 1, rig.Attempts())
+}
+
+func TestAdmin_TouchOnlyHost(t *testing.T) {
+	touchAddr, rig := serveTouchHost(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	admin := yubictl.NewSvcClient("http://"+touchAddr,
+		yubictl.WithAdminToken(adminToken),
+		yubictl.WithRetryCount(0),
+	)
+	require.NoError(t, admin.TouchPort(ctx, "", 2, yubictl.TouchWithDuration(10*time.Millisecond)))
+	require.Len(t, rig.Touches(), 1)
+
+	// Yubikey routes need the ykman, which the touch host doesn't have
+	_, err := admin.Yubikeys(ctx)
+	require.Error(t, err)
+}
+
+func TestAdmin_TouchIdempotencyKey(t *testing.T) {
+	touchAddr, rig := serveTouchHost(t)
+
+	touch := func(key string) {
+		req, err := http.NewRequest(http.MethodPost, "http://"+touchAddr+"/v1/admin/touch",
+			bytes.NewBufferString(`{"port": 1, "duration": 10000000}`))
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		req.Header.Set(yubictl.HeaderIdempotencyKey, key)
+
+		rsp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		_ = rsp.Body.Close()
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+	}
+
+	touch("first")
+	touch("first")
+	require.Len(t, rig.Touches(), 1)
+
+	touch("second")
+	require.Len(t, rig.Touches(), 2)
+}
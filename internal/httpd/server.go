@@ -4,7 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	mlog "github.com/gofiber/fiber/v2/middleware/logger"
@@ -22,12 +25,21 @@ import (
 
 const DefaultAddr = "127.0.0.1:3000"
 
+// touchSlack is how long a touch may exceed its gesture duration due to the toucher latency.
+const touchSlack = 10 * time.Second
+
+// touchWaitTimeout limits how long a touch status request waits for the touch to finish.
+const touchWaitTimeout = 30 * time.Second
+
 type Server struct {
-	addr  string
-	touch touchctl.Toucher
-	yk    *ykman.YkMan
-	app   *fiber.App
-	log   zerolog.Logger
+	addr          string
+	adminToken    string
+	touchers      *touchctl.Set
+	yk            *ykman.YkMan
+	app           *fiber.App
+	log           zerolog.Logger
+	portTouchesMu sync.Mutex
+	portTouches   map[string]*keyedTouch
 }
 
 func NewServer(opts ...Option) (*Server, error) {
@@ -40,7 +52,8 @@ func NewServer(opts ...Option) (*Server, error) {
 		app: fiber.New(fiber.Config{
 			ErrorHandler: errorHandler,
 		}),
-		log: l,
+		log:         l,
+		portTouches: make(map[string]*keyedTouch),
 	}
 
 	for _, opt := range opts {
@@ -59,6 +72,11 @@ func (s *Server) ListenAndServe() error {
 		_ = ln.Close()
 	}()
 
+	return s.Serve(ln)
+}
+
+// Serve serves the API on the already created listener.
+func (s *Server) Serve(ln net.Listener) error {
 	return s.app.Listener(ln)
 }
 
@@ -87,88 +105,192 @@ func (s *Server) init() error {
 		})
 
 		router.Post("/acquire", func(c *fiber.Ctx) error {
-			id := utils.UUIDv4()
-			if s.yk == nil {
-				return &fiber.Error{
-					Code:    fiber.StatusBadRequest,
-					Message: "ykman not initialized",
+			var req yubictl.AcquireReq
+			if len(c.Body()) > 0 {
+				if err := c.BodyParser(&req); err != nil {
+					return fmt.Errorf("parse body: %w", err)
 				}
 			}
 
-			yk, err := s.yk.Acquire(id)
+			acquireReq, err := parseAcquireReq(req)
 			if err != nil {
-				if errors.Is(err, ykman.ErrNoFreeYubikey) {
-					return &yubictl.ServiceError{
-						HttpCode: fiber.StatusGone,
-						Code:     yubictl.ServiceErrorNoFreeYubikey,
-						Msg:      fmt.Sprintf("acuire free yubikey: %v", err),
-					}
+				return &fiber.Error{
+					Code:    fiber.StatusBadRequest,
+					Message: fmt.Sprintf("parse request: %v", err),
 				}
+			}
 
-				return fmt.Errorf("acquire free yubikey: %w", err)
+			id := utils.UUIDv4()
+			acquireReq.ClientIDs = []string{id}
+			yks, err := s.acquire(c, acquireReq, req.Wait)
+			if err != nil {
+				return err
 			}
 
+			yk := yks[0]
 			s.log.Info().
 				Str("client_id", id).
 				Str("path", yk.Path()).
 				Uint32("yk_serial", yk.Serial()).
 				Msg("acquired yubikey")
 
-			return c.JSON(yubictl.AcquireRsp{
-				ID:     id,
-				Serial: yk.Serial(),
-			})
+			return c.JSON(acquireRsp(yk))
 		})
 
-		router.Post("/touch", func(c *fiber.Ctx) error {
-			var req yubictl.TouchReq
+		router.Post("/acquire-group", func(c *fiber.Ctx) error {
+			var req yubictl.AcquireGroupReq
 			if err := c.BodyParser(&req); err != nil {
 				return fmt.Errorf("parse body: %w", err)
 			}
 
-			yk, err := s.ykByClient(req.ID)
+			if req.Count <= 0 {
+				return &fiber.Error{
+					Code:    fiber.StatusBadRequest,
+					Message: fmt.Sprintf("invalid yubikeys count: %d", req.Count),
+				}
+			}
+
+			acquireReq, err := parseAcquireReq(req.AcquireReq)
 			if err != nil {
 				return &fiber.Error{
 					Code:    fiber.StatusBadRequest,
-					Message: fmt.Sprintf("lookup yubikey: %v", err),
+					Message: fmt.Sprintf("parse request: %v", err),
 				}
 			}
 
-			if s.touch == nil {
+			groupID := utils.UUIDv4()
+			acquireReq.GroupID = groupID
+			acquireReq.ClientIDs = make([]string, req.Count)
+			for i := range acquireReq.ClientIDs {
+				acquireReq.ClientIDs[i] = utils.UUIDv4()
+			}
+			acquireReq.Constraints = ykman.Constraints{
+				SameHub:          req.SameHub,
+				DistinctVersions: req.DistinctVersions,
+			}
+
+			yks, err := s.acquire(c, acquireReq, req.Wait)
+			if err != nil {
+				return err
+			}
+
+			out := yubictl.AcquireGroupRsp{
+				ID:       groupID,
+				Yubikeys: make([]yubictl.AcquireRsp, len(yks)),
+			}
+			for i, yk := range yks {
+				s.log.Info().
+					Str("group_id", groupID).
+					Str("client_id", acquireReq.ClientIDs[i]).
+					Str("path", yk.Path()).
+					Uint32("yk_serial", yk.Serial()).
+					Msg("acquired yubikey")
+
+				out.Yubikeys[i] = acquireRsp(yk)
+			}
+
+			return c.JSON(out)
+		})
+
+		router.Post("/touch", func(c *fiber.Ctx) error {
+			var req yubictl.TouchReq
+			if err := c.BodyParser(&req); err != nil {
+				return fmt.Errorf("parse body: %w", err)
+			}
+
+			yk, err := s.ykByClient(req.ID)
+			if err != nil {
 				return &fiber.Error{
 					Code:    fiber.StatusBadRequest,
-					Message: "touchctl not initialized",
+					Message: fmt.Sprintf("lookup yubikey: %v", err),
 				}
 			}
 
-			port := yk.Port()
-			if port == 0 {
+			if yk.Port() == 0 {
 				return &fiber.Error{
 					Code:    fiber.StatusNotAcceptable,
 					Message: "yubikey have no port configured",
 				}
 			}
 
-			if err := s.touch.Touch(port, req.Delay, req.Duration); err != nil {
-				s.log.Error().
-					Str("client_id", req.ID).
-					Str("path", yk.Path()).
-					Uint32("yk_serial", yk.Serial()).
-					Msg("touch failed")
+			gesture, err := touchGesture(req.Delay, req.Duration, req.Preset, req.Steps)
+			if err != nil {
 				return err
 			}
 
+			touchID := utils.CopyString(c.Get(yubictl.HeaderIdempotencyKey))
+			if touchID == "" {
+				touchID = utils.UUIDv4()
+			}
+
+			status, err := s.yk.ScheduleTouch(touchID, yk, gesture)
+			if err != nil {
+				return touchError(err)
+			}
+
 			s.log.Info().
 				Str("client_id", req.ID).
+				Str("touch_id", status.ID).
 				Str("path", yk.Path()).
 				Uint32("yk_serial", yk.Serial()).
-				Msg("touch yubikey")
+				Str("toucher", yk.ToucherID()).
+				Int("presses", len(gesture)).
+				Msg("touch scheduled")
 
-			return nil
+			return c.JSON(touchStatusRsp(status))
+		})
+
+		router.Post("/touch/status", func(c *fiber.Ctx) error {
+			var req yubictl.TouchStatusReq
+			if err := c.BodyParser(&req); err != nil {
+				return fmt.Errorf("parse body: %w", err)
+			}
+
+			if !req.Wait {
+				status, err := s.yk.TouchStatus(req.ID, req.TouchID)
+				if err != nil {
+					return touchError(err)
+				}
+
+				return c.JSON(touchStatusRsp(status))
+			}
+
+			ctx, cancel := s.requestContext(c, touchWaitTimeout)
+			defer cancel()
+
+			status, err := s.yk.WaitTouch(ctx, req.ID, req.TouchID)
+			if err != nil {
+				return touchError(err)
+			}
+
+			return c.JSON(touchStatusRsp(status))
+		})
+
+		router.Post("/touch/cancel", func(c *fiber.Ctx) error {
+			var req yubictl.TouchCancelReq
+			if err := c.BodyParser(&req); err != nil {
+				return fmt.Errorf("parse body: %w", err)
+			}
+
+			ctx, cancel := s.requestContext(c, touchWaitTimeout)
+			defer cancel()
+
+			status, err := s.yk.CancelTouch(ctx, req.ID, req.TouchID)
+			if err != nil {
+				return touchError(err)
+			}
+
+			s.log.Info().
+				Str("client_id", req.ID).
+				Str("touch_id", req.TouchID).
+				Str("state", string(status.State)).
+				Msg("touch canceled")
+
+			return c.JSON(touchStatusRsp(status))
 		})
 
 		router.Post("/reboot", func(c *fiber.Ctx) error {
-			var req yubictl.TouchReq
+			var req yubictl.RebootReq
 			if err := c.BodyParser(&req); err != nil {
 				return fmt.Errorf("parse body: %w", err)
 			}
@@ -181,26 +303,45 @@ func (s *Server) init() error {
 				}
 			}
 
-			if err := yk.Reboot(); err != nil {
+			var strategy ykman.RebootStrategyKind
+			if err := strategy.UnmarshalText([]byte(req.Strategy)); err != nil {
+				return &fiber.Error{
+					Code:    fiber.StatusBadRequest,
+					Message: err.Error(),
+				}
+			}
+
+			ctx, cancel := s.requestContext(c, s.yk.RebootTimeout())
+			downtime, err := s.yk.Reboot(ctx, yk, strategy)
+			cancel()
+			if err != nil {
 				s.log.Error().
+					Err(err).
 					Str("client_id", req.ID).
 					Str("path", yk.Path()).
 					Uint32("yk_serial", yk.Serial()).
+					Str("strategy", string(strategy)).
 					Msg("reboot failed")
-				return err
+				return rebootError("reboot yubikey", err)
 			}
 
 			s.log.Info().
 				Str("client_id", req.ID).
 				Str("path", yk.Path()).
 				Uint32("yk_serial", yk.Serial()).
+				Str("strategy", string(strategy)).
+				Dur("downtime", downtime).
 				Msg("reboot yubikey")
 
-			return nil
+			return c.JSON(yubictl.RebootRsp{
+				Serial:   yk.Serial(),
+				Path:     yk.Path(),
+				Downtime: downtime,
+			})
 		})
 
-		router.Post("/ping", func(c *fiber.Ctx) error {
-			var req yubictl.TouchReq
+		router.Post("/power-cycle", func(c *fiber.Ctx) error {
+			var req yubictl.PowerCycleReq
 			if err := c.BodyParser(&req); err != nil {
 				return fmt.Errorf("parse body: %w", err)
 			}
@@ -213,22 +354,40 @@ func (s *Server) init() error {
 				}
 			}
 
+			ctx, cancel := s.requestContext(c, s.yk.PowerOffDelay()+s.yk.RebootTimeout())
+			downtime, err := s.yk.PowerCycle(ctx, yk)
+			cancel()
+			if err != nil {
+				s.log.Error().
+					Err(err).
+					Str("client_id", req.ID).
+					Str("location", yk.Location()).
+					Uint32("yk_serial", yk.Serial()).
+					Msg("power cycle failed")
+				return rebootError("power cycle yubikey", err)
+			}
+
 			s.log.Info().
 				Str("client_id", req.ID).
 				Str("path", yk.Path()).
 				Uint32("yk_serial", yk.Serial()).
-				Msg("ping yubikey")
+				Dur("downtime", downtime).
+				Msg("power cycle yubikey")
 
-			return yk.Ping()
+			return c.JSON(yubictl.PowerCycleRsp{
+				Serial:   yk.Serial(),
+				Path:     yk.Path(),
+				Downtime: downtime,
+			})
 		})
 
-		router.Post("/release", func(c *fiber.Ctx) error {
-			var req yubictl.TouchReq
+		router.Post("/info", func(c *fiber.Ctx) error {
+			var req yubictl.InfoReq
 			if err := c.BodyParser(&req); err != nil {
 				return fmt.Errorf("parse body: %w", err)
 			}
 
-			yk, err := s.ykByClient(req.ID)
+			yks, err := s.yksByLease(req.ID)
 			if err != nil {
 				return &fiber.Error{
 					Code:    fiber.StatusBadRequest,
@@ -236,23 +395,138 @@ func (s *Server) init() error {
 				}
 			}
 
-			if err := yk.Release(); err != nil {
-				s.log.Error().
+			out := yubictl.InfoRsp{
+				Yubikeys: make([]yubictl.YubikeyInfo, len(yks)),
+			}
+			for i, yk := range yks {
+				out.Yubikeys[i] = yubikeyInfo(yk)
+			}
+
+			return c.JSON(out)
+		})
+
+		router.Post("/ping", func(c *fiber.Ctx) error {
+			var req yubictl.PingReq
+			if err := c.BodyParser(&req); err != nil {
+				return fmt.Errorf("parse body: %w", err)
+			}
+
+			if _, err := s.yksByLease(req.ID); err != nil {
+				return &fiber.Error{
+					Code:    fiber.StatusBadRequest,
+					Message: fmt.Sprintf("lookup yubikey: %v", err),
+				}
+			}
+
+			yks, err := s.yk.Ping(req.ID)
+			if err != nil {
+				return err
+			}
+
+			out := yubictl.PingRsp{
+				Yubikeys: make([]yubictl.LeaseStatus, len(yks)),
+			}
+			for i, yk := range yks {
+				s.log.Info().
 					Str("client_id", req.ID).
 					Str("path", yk.Path()).
 					Uint32("yk_serial", yk.Serial()).
-					Msg("release failed")
+					Bool("missing", yk.IsMissing()).
+					Msg("ping yubikey")
+
+				out.Yubikeys[i] = leaseStatus(yk)
+			}
+
+			return c.JSON(out)
+		})
+
+		router.Post("/renew", func(c *fiber.Ctx) error {
+			var req yubictl.RenewReq
+			if err := c.BodyParser(&req); err != nil {
+				return fmt.Errorf("parse body: %w", err)
+			}
+
+			if _, err := s.yksByLease(req.ID); err != nil {
+				return &fiber.Error{
+					Code:    fiber.StatusBadRequest,
+					Message: fmt.Sprintf("lookup yubikey: %v", err),
+				}
+			}
+
+			yks, err := s.yk.Ping(req.ID)
+			if err != nil {
 				return err
 			}
 
+			var out yubictl.RenewRsp
+			for _, yk := range yks {
+				lease := yk.Lease()
+				if expiresAt := lease.ExpiresAt(); out.ExpiresAt.IsZero() || expiresAt.Before(out.ExpiresAt) {
+					out.ExpiresAt = expiresAt
+				}
+
+				if deadline := lease.Deadline(); !deadline.IsZero() {
+					remaining := max(time.Until(deadline), 0)
+					if out.RemainingLifetime == 0 || remaining < out.RemainingLifetime {
+						out.RemainingLifetime = remaining
+					}
+				}
+
+				if interval := lease.PingInterval(); out.PingInterval == 0 || interval < out.PingInterval {
+					out.PingInterval = interval
+				}
+
+				out.Yubikeys = append(out.Yubikeys, leaseStatus(yk))
+			}
+
 			s.log.Info().
 				Str("client_id", req.ID).
-				Str("path", yk.Path()).
-				Uint32("yk_serial", yk.Serial()).
-				Msg("release yubikey")
+				Time("expires_at", out.ExpiresAt).
+				Msg("renew lease")
+
+			return c.JSON(out)
+		})
+
+		router.Post("/release", func(c *fiber.Ctx) error {
+			var req yubictl.ReleaseReq
+			if err := c.BodyParser(&req); err != nil {
+				return fmt.Errorf("parse body: %w", err)
+			}
+
+			if _, err := s.yksByLease(req.ID); err != nil {
+				return &fiber.Error{
+					Code:    fiber.StatusBadRequest,
+					Message: fmt.Sprintf("lookup yubikey: %v", err),
+				}
+			}
+
+			yks, err := s.yk.Release(req.ID)
+			if err != nil {
+				s.log.Error().
+					Err(err).
+					Str("client_id", req.ID).
+					Msg("release failed")
+				return err
+			}
+
+			for _, yk := range yks {
+				s.log.Info().
+					Str("client_id", req.ID).
+					Str("path", yk.Path()).
+					Uint32("yk_serial", yk.Serial()).
+					Msg("release yubikey")
+			}
 
 			return nil
 		})
+
+		// the admin API controls the pool and the touchers directly, so it never goes unauthenticated
+		if s.adminToken == "" {
+			s.log.Warn().Msg("no admin token configured, admin API is disabled")
+			return
+		}
+
+		router.Route("/admin", s.initAdmin)
 	})
 
 	return nil
@@ -270,6 +544,262 @@ func (s *Server) ykByClient(clientID string) (*ykman.Yubikey, error) {
 	return s.yk.ForClient(clientID)
 }
 
+func (s *Server) acquire(c *fiber.Ctx, req ykman.AcquireReq, wait time.Duration) ([]*ykman.Yubikey, error) {
+	if s.yk == nil {
+		return nil, &fiber.Error{
+			Code:    fiber.StatusBadRequest,
+			Message: "ykman not initialized",
+		}
+	}
+
+	var yks []*ykman.Yubikey
+	var err error
+	if wait > 0 {
+		ctx, cancel := s.requestContext(c, wait)
+		yks, err = s.yk.Acquire(ctx, req)
+		cancel()
+	} else {
+		yks, err = s.yk.TryAcquire(req)
+	}
+
+	if err != nil {
+		return nil, acquireError(err)
+	}
+
+	return yks, nil
+}
+
+func (s *Server) yksByLease(leaseID string) ([]*ykman.Yubikey, error) {
+	if s.yk == nil {
+		return nil, errors.New("ykman not initialized")
+	}
+
+	if leaseID == "" {
+		return nil, errors.New("leaseID is empty")
+	}
+
+	return s.yk.Lease(leaseID)
+}
+
+// requestContext bounds a long-running request by timeout and by the client connection.
+// The fasthttp request context is not a proper parent here: it must not be used once the handler returns.
+func (s *Server) requestContext(c *fiber.Ctx, timeout time.Duration) (context.Context, context.CancelFunc) {
+	connCtx, connCancel := xnet.WatchConn(c.UserContext(), c.Context().Conn(), xnet.DefaultWatchInterval)
+	ctx, cancel := context.WithTimeout(connCtx, timeout)
+	return ctx, func() {
+		cancel()
+		connCancel()
+	}
+}
+
+// touchGesture resolves the requested touch: a preset, explicit steps or a single press.
+func touchGesture(delay, duration time.Duration, preset yubictl.TouchPreset, steps []yubictl.TouchStep) (touchctl.Gesture, error) {
+	var gesture touchctl.Gesture
+	switch {
+	case preset != "" && len(steps) > 0:
+		return nil, &fiber.Error{
+			Code:    fiber.StatusBadRequest,
+			Message: "touch preset and steps are mutually exclusive",
+		}
+
+	case preset != "":
+		var err error
+		gesture, err = touchctl.Preset(string(preset))
+		if err != nil {
+			return nil, &fiber.Error{
+				Code:    fiber.StatusBadRequest,
+				Message: err.Error(),
+			}
+		}
+
+	case len(steps) > 0:
+		gesture = make(touchctl.Gesture, len(steps))
+		for i, step := range steps {
+			gesture[i] = touchctl.Press{
+				Delay:    step.Delay,
+				Duration: step.Duration,
+			}
+		}
+
+	default:
+		gesture = touchctl.Gesture{
+			{Delay: delay, Duration: duration},
+		}
+	}
+
+	if err := gesture.Validate(); err != nil {
+		return nil, &fiber.Error{
+			Code:    fiber.StatusBadRequest,
+			Message: fmt.Sprintf("invalid touch: %v", err),
+		}
+	}
+
+	return gesture, nil
+}
+
+func touchStatusRsp(status ykman.TouchStatus) yubictl.TouchStatus {
+	out := yubictl.TouchStatus{
+		TouchID:     status.ID,
+		Serial:      status.Serial,
+		State:       yubictl.TouchState(status.State),
+		ScheduledAt: status.ScheduledAt,
+		StartedAt:   status.StartedAt,
+		FinishedAt:  status.FinishedAt,
+	}
+
+	if status.Err != nil {
+		out.Error = status.Err.Error()
+	}
+
+	return out
+}
+
+func acquireRsp(yk *ykman.Yubikey) yubictl.AcquireRsp {
+	lease := yk.Lease()
+	return yubictl.AcquireRsp{
+		ID:           lease.ClientID,
+		Serial:       yk.Serial(),
+		TTL:          lease.TTL,
+		MaxLifetime:  lease.MaxLifetime,
+		ExpiresAt:    lease.ExpiresAt(),
+		PingInterval: lease.PingInterval(),
+		Info:         yubikeyInfo(yk),
+	}
+}
+
+func yubikeyInfo(yk *ykman.Yubikey) yubictl.YubikeyInfo {
+	return yubictl.YubikeyInfo{
+		Serial:     yk.Serial(),
+		Vendor:     yk.Vendor(),
+		Model:      yk.Model(),
+		Version:    yk.Version().String(),
+		FormFactor: yk.FormFactor().String(),
+		Interfaces: yk.Interfaces().Names(),
+		Path:       yk.Path(),
+		Reader:     yk.Reader(),
+		Location:   yk.Location(),
+		Toucher:    yk.ToucherID(),
+		Port:       yk.Port(),
+	}
+}
+
+func leaseStatus(yk *ykman.Yubikey) yubictl.LeaseStatus {
+	lease := yk.Lease()
+	missingSince := yk.MissingSince()
+	return yubictl.LeaseStatus{
+		ID:           lease.ClientID,
+		Serial:       yk.Serial(),
+		ExpiresAt:    lease.ExpiresAt(),
+		Missing:      !missingSince.IsZero(),
+		MissingSince: missingSince,
+	}
+}
+
+func parseAcquireReq(req yubictl.AcquireReq) (ykman.AcquireReq, error) {
+	sel, err := parseSelector(req)
+	if err != nil {
+		return ykman.AcquireReq{}, fmt.Errorf("parse selector: %w", err)
+	}
+
+	if req.TTL < 0 || req.MaxLifetime < 0 {
+		return ykman.AcquireReq{}, errors.New("negative lease limits")
+	}
+
+	return ykman.AcquireReq{
+		Selector:    sel,
+		TTL:         req.TTL,
+		MaxLifetime: req.MaxLifetime,
+	}, nil
+}
+
+func parseSelector(req yubictl.AcquireReq) (ykman.Selector, error) {
+	sel := ykman.Selector{
+		Serial:    req.Serial,
+		Location:  req.Location,
+		Touchable: req.Touchable,
+	}
+
+	var err error
+	if sel.MinVersion, err = ykman.ParseVersion(req.MinVersion); err != nil {
+		return sel, fmt.Errorf("min version: %w", err)
+	}
+
+	if sel.MaxVersion, err = ykman.ParseVersion(req.MaxVersion); err != nil {
+		return sel, fmt.Errorf("max version: %w", err)
+	}
+
+	if err := sel.FormFactor.UnmarshalText([]byte(req.FormFactor)); err != nil {
+		return sel, err
+	}
+
+	if sel.Interfaces, err = ykman.ParseInterfaces(req.Interfaces); err != nil {
+		return sel, err
+	}
+
+	return sel, nil
+}
+
+func acquireError(err error) error {
+	switch {
+	case errors.Is(err, ykman.ErrNoFreeYubikey):
+		return &yubictl.ServiceError{
+			HttpCode: fiber.StatusGone,
+			Code:     yubictl.ServiceErrorNoFreeYubikey,
+			Msg:      fmt.Sprintf("acuire free yubikey: %v", err),
+		}
+	case errors.Is(err, ykman.ErrNoMatchingYubikey):
+		return &yubictl.ServiceError{
+			HttpCode: fiber.StatusNotFound,
+			Code:     yubictl.ServiceErrorNoMatchingYubikey,
+			Msg:      fmt.Sprintf("acuire matching yubikey: %v", err),
+		}
+	default:
+		return fmt.Errorf("acquire free yubikey: %w", err)
+	}
+}
+
+func rebootError(op string, err error) error {
+	switch {
+	case errors.Is(err, ykman.ErrRebootTimeout):
+		return &yubictl.ServiceError{
+			HttpCode: fiber.StatusGatewayTimeout,
+			Code:     yubictl.ServiceErrorRebootTimeout,
+			Msg:      fmt.Sprintf("%s: %v", op, err),
+		}
+	case errors.Is(err, ykman.ErrPowerUnsupported), errors.Is(err, ykman.ErrResetUnsupported):
+		return &yubictl.ServiceError{
+			HttpCode: fiber.StatusNotImplemented,
+			Code:     yubictl.ServiceErrorNotSupported,
+			Msg:      fmt.Sprintf("%s: %v", op, err),
+		}
+	default:
+		return fmt.Errorf("%s: %w", op, err)
+	}
+}
+
+func touchError(err error) error {
+	switch {
+	case errors.Is(err, ykman.ErrUnknownTouch):
+		return &yubictl.ServiceError{
+			HttpCode: fiber.StatusNotFound,
+			Code:     yubictl.ServiceErrorUnknownTouch,
+			Msg:      err.Error(),
+		}
+	case errors.Is(err, ykman.ErrNoTouchPoint):
+		return &fiber.Error{
+			Code:    fiber.StatusNotAcceptable,
+			Message: err.Error(),
+		}
+	case errors.Is(err, ykman.ErrTouchExists):
+		return &fiber.Error{
+			Code:    fiber.StatusConflict,
+			Message: err.Error(),
+		}
+	default:
+		return fmt.Errorf("touch: %w", err)
+	}
+}
+
 func errorHandler(ctx *fiber.Ctx, err error) error {
 	code := fiber.StatusInternalServerError
 
@@ -277,10 +807,13 @@ func errorHandler(ctx *fiber.Ctx, err error) error {
 	var fiberErr *fiber.Error
 	switch {
 	case errors.As(err, &svcErr):
-		err = ctx.Status(fiber.StatusInternalServerError).JSON(svcErr)
+		if svcErr.HttpCode != 0 {
+			code = svcErr.HttpCode
+		}
+		err = ctx.Status(code).JSON(svcErr)
 
 	case errors.As(err, &fiberErr):
-		err = ctx.Status(code).JSON(yubictl.ServiceError{
+		err = ctx.Status(fiberErr.Code).JSON(yubictl.ServiceError{
 			Code: yubictl.ServiceErrorInternalError,
 			Msg:  err.Error(),
 		})
@@ -0,0 +1,120 @@
+package httpd_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/httpd"
+	"github.com/buglloc/yubictld/internal/simulator"
+	"github.com/buglloc/yubictld/internal/ykman"
+	"github.com/buglloc/yubictld/pkg/yubictl"
+)
+
+// serveKeys runs a yubictld with a single simulated Yubikey wired to a local toucher.
+func serveKeys(t *testing.T, opts ...simulator.Option) (string, *simulator.Toucher) {
+	t.Helper()
+
+	sim := simulator.NewEnumerator(1, append([]simulator.Option{simulator.WithRebootLatency(0)}, opts...)...)
+	rig := simulator.NewToucher(sim.HubLocation())
+	discovery, err := ykman.NewManualDiscovery(map[uint32]ykman.TouchPoint{
+		sim.Devices()[0].Serial(): {ToucherID: "rig", Toucher: rig, Port: 1},
+	})
+	require.NoError(t, err)
+
+	yk := ykman.NewYkMan(
+		ykman.WithEnumerator(sim),
+		ykman.WithDiscovery(discovery),
+		ykman.WithReapInterval(0),
+	)
+	t.Cleanup(func() {
+		_ = yk.Close()
+	})
+	require.NoError(t, yk.ReloadDevices())
+
+	return serve(t, httpd.WithYkMan(yk)), rig
+}
+
+func TestTouch_RetriedScheduleTouchesOnce(t *testing.T) {
+	addr, rig := serveKeys(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key, err := yubictl.NewSvcClient("http://" + addr).Acquire(ctx)
+	require.NoError(t, err)
+	defer func() { _ = key.Close(ctx) }()
+
+	// a retry of a request which response was lost
+	schedule := func(idempotencyKey string) yubictl.TouchStatus {
+		body := fmt.Sprintf(`{"id": %q, "duration": 10000000}`, key.ID())
+		req, err := http.NewRequest(http.MethodPost, "http://"+addr+"/v1/touch", bytes.NewBufferString(body))
+		require.NoError(t, err)
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(yubictl.HeaderIdempotencyKey, idempotencyKey)
+
+		rsp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = rsp.Body.Close() }()
+		require.Equal(t, http.StatusOK, rsp.StatusCode)
+
+		var status yubictl.TouchStatus
+		require.NoError(t, json.NewDecoder(rsp.Body).Decode(&status))
+		return status
+	}
+
+	first := schedule("retried")
+	require.Equal(t, first.TouchID, schedule("retried").TouchID)
+
+	status, err := key.WaitTouch(ctx, first.TouchID)
+	require.NoError(t, err)
+	require.Equal(t, yubictl.TouchStateDone, status.State)
+	require.Len(t, rig.Touches(), 1)
+
+	// the regular client schedules a touch per call
+	require.NoError(t, key.Touch(ctx, yubictl.TouchWithDuration(10*time.Millisecond)))
+	require.Len(t, rig.Touches(), 2)
+}
+
+func TestInfo_FollowsReboot(t *testing.T) {
+	addr, _ := serveKeys(t,
+		simulator.WithVersions(ykman.Version{Major: 5, Minor: 7, Patch: 1}),
+		simulator.WithFormFactor(ykman.FormFactorUSBCNano),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	key, err := yubictl.NewSvcClient("http://" + addr).Acquire(ctx)
+	require.NoError(t, err)
+	defer func() { _ = key.Close(ctx) }()
+
+	info := key.Info()
+	require.Equal(t, key.Serial(), info.Serial)
+	require.Equal(t, ykman.VendorYubico, info.Vendor)
+	require.Equal(t, "5.7.1", info.Version)
+	require.Equal(t, "usb-c-nano", info.FormFactor)
+	require.NotEmpty(t, info.Interfaces)
+	require.Equal(t, "1-1.1", info.Location)
+	require.Equal(t, "rig", info.Toucher)
+	require.Equal(t, 1, info.Port)
+
+	// a rebooted key is re-enumerated under a new hidraw node
+	_, err = key.Reboot(ctx)
+	require.NoError(t, err)
+
+	fresh, err := key.FetchInfo(ctx)
+	require.NoError(t, err)
+	require.NotEqual(t, info.Path, fresh.Path)
+	require.Equal(t, fresh, key.Info())
+
+	fresh.Path = info.Path
+	require.Equal(t, info, fresh)
+}
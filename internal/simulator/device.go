@@ -0,0 +1,212 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+type Op string
+
+const (
+	OpInfo      Op = "info"
+	OpReboot    Op = "reboot"
+	OpResetFIDO Op = "reset-fido"
+)
+
+var _ ykman.Device = (*Device)(nil)
+
+// Device is a simulated Yubikey.
+type Device struct {
+	serial        uint32
+	version       ykman.Version
+	formFactor    ykman.FormFactor
+	vendor        string
+	location      string
+	shutdownDelay time.Duration
+	rebootLatency time.Duration
+	mu            sync.Mutex
+	generation    int
+	plugged       bool
+	offlineSince  time.Time
+	offlineUntil  time.Time
+	failures      map[Op]error
+	stalls        map[Op]chan struct{}
+	reboots       int
+	resets        int
+}
+
+func (d *Device) Serial() uint32 {
+	return d.serial
+}
+
+func (d *Device) Path() string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// a re-enumerated device gets a new hidraw node, just like the real one
+	generation := d.generation
+	if time.Now().Before(d.offlineSince) {
+		// the reboot is not in effect yet
+		generation--
+	}
+
+	return fmt.Sprintf("/dev/hidraw-sim%d.%d", d.serial, generation)
+}
+
+func (d *Device) Location() string {
+	return d.location
+}
+
+func (d *Device) String() string {
+	return fmt.Sprintf("simulated Yubikey #%d at %s", d.serial, d.location)
+}
+
+func (d *Device) Info() (ykman.DeviceInfo, error) {
+	if err := d.check(OpInfo); err != nil {
+		return ykman.DeviceInfo{}, err
+	}
+
+	return ykman.DeviceInfo{
+		Serial:     d.serial,
+		Version:    d.version,
+		FormFactor: d.formFactor,
+		Interfaces: ykman.InterfaceOTP | ykman.InterfaceFIDO | ykman.InterfaceCCID,
+		Vendor:     d.vendor,
+		Model:      ykman.ModelYubikey,
+	}, nil
+}
+
+func (d *Device) Reboot() error {
+	if d.vendor != ykman.VendorYubico {
+		return fmt.Errorf("%s: reboot is supported by Yubikeys only", d)
+	}
+
+	if err := d.check(OpReboot); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.reboots++
+	d.generation++
+	d.offlineSince = time.Now().Add(d.shutdownDelay)
+	d.offlineUntil = d.offlineSince.Add(d.rebootLatency)
+	return nil
+}
+
+func (d *Device) ResetFIDO(ctx context.Context) error {
+	if err := d.check(OpResetFIDO); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.resets++
+	return nil
+}
+
+// Fail makes every following op fail with err, nil err restores normal behavior.
+func (d *Device) Fail(op Op, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if err == nil {
+		delete(d.failures, op)
+		return
+	}
+
+	d.failures[op] = err
+}
+
+// Stall blocks every following op until the returned resume func is called.
+func (d *Device) Stall(op Op) func() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	stall := make(chan struct{})
+	if d.stalls == nil {
+		d.stalls = make(map[Op]chan struct{})
+	}
+	d.stalls[op] = stall
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			d.mu.Lock()
+			delete(d.stalls, op)
+			d.mu.Unlock()
+			close(stall)
+		})
+	}
+}
+
+// Unplug removes the device from the bus until Plug is called.
+func (d *Device) Unplug() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.plugged = false
+}
+
+func (d *Device) Plug() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.plugged = true
+	d.generation++
+}
+
+func (d *Device) Reboots() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.reboots
+}
+
+func (d *Device) FIDOResets() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.resets
+}
+
+func (d *Device) isOnline() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.plugged && !d.rebootingLocked()
+}
+
+func (d *Device) check(op Op) error {
+	d.mu.Lock()
+	stall := d.stalls[op]
+	d.mu.Unlock()
+
+	if stall != nil {
+		<-stall
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if !d.plugged || d.rebootingLocked() {
+		return fmt.Errorf("%s: device is gone", op)
+	}
+
+	return d.failures[op]
+}
+
+func (d *Device) rebootingLocked() bool {
+	now := time.Now()
+	return !now.Before(d.offlineSince) && now.Before(d.offlineUntil)
+}
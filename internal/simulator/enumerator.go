@@ -0,0 +1,117 @@
+package simulator
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+var _ ykman.Enumerator = (*Enumerator)(nil)
+
+// Enumerator is an in-memory device backend with a fixed set of simulated Yubikeys
+// plugged into a single hub, one per port starting from port 1.
+type Enumerator struct {
+	serialBase    uint32
+	versions      []ykman.Version
+	formFactor    ykman.FormFactor
+	vendor        string
+	hubLocation   string
+	shutdownDelay time.Duration
+	rebootLatency time.Duration
+	mu            sync.Mutex
+	devices       []*Device
+	failure       error
+}
+
+func NewEnumerator(count int, opts ...Option) *Enumerator {
+	e := &Enumerator{
+		serialBase:    DefaultSerialBase,
+		versions:      []ykman.Version{DefaultVersion},
+		formFactor:    ykman.FormFactorUSBAKeychain,
+		vendor:        ykman.VendorYubico,
+		hubLocation:   DefaultHubLocation,
+		rebootLatency: DefaultRebootLatency,
+	}
+
+	for _, opt := range opts {
+		opt(e)
+	}
+
+	e.devices = make([]*Device, count)
+	for i := range e.devices {
+		e.devices[i] = &Device{
+			serial:        e.serialBase + uint32(i),
+			version:       e.versions[i%len(e.versions)],
+			formFactor:    e.formFactor,
+			vendor:        e.vendor,
+			location:      fmt.Sprintf("%s.%d", e.hubLocation, i+1),
+			shutdownDelay: e.shutdownDelay,
+			rebootLatency: e.rebootLatency,
+			plugged:       true,
+			failures:      make(map[Op]error),
+		}
+	}
+
+	return e
+}
+
+func (e *Enumerator) Enumerate() ([]ykman.Device, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.failure != nil {
+		return nil, e.failure
+	}
+
+	var out []ykman.Device
+	for _, dev := range e.devices {
+		if dev.isOnline() {
+			out = append(out, dev)
+		}
+	}
+
+	return out, nil
+}
+
+// Fail makes every following enumeration fail with err, nil err restores normal behavior.
+func (e *Enumerator) Fail(err error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.failure = err
+}
+
+func (e *Enumerator) Devices() []*Device {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	out := make([]*Device, len(e.devices))
+	copy(out, e.devices)
+	return out
+}
+
+func (e *Enumerator) Device(serial uint32) (*Device, bool) {
+	for _, dev := range e.Devices() {
+		if dev.serial == serial {
+			return dev, true
+		}
+	}
+
+	return nil, false
+}
+
+// Ports returns the touch port of every simulated Yubikey by its serial.
+func (e *Enumerator) Ports() map[uint32]int {
+	out := make(map[uint32]int)
+	for i, dev := range e.Devices() {
+		out[dev.serial] = i + 1
+	}
+
+	return out
+}
+
+func (e *Enumerator) HubLocation() string {
+	return e.hubLocation
+}
@@ -0,0 +1,68 @@
+package simulator
+
+import (
+	"time"
+
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+const (
+	DefaultSerialBase    uint32 = 10000000
+	DefaultHubLocation          = "1-1"
+	DefaultRebootLatency        = time.Second
+)
+
+var DefaultVersion = ykman.Version{Major: 5, Minor: 7, Patch: 1}
+
+type Option func(*Enumerator)
+
+// WithSerialBase sets the serial of the first simulated Yubikey, the next ones are numbered sequentially.
+func WithSerialBase(serial uint32) Option {
+	return func(e *Enumerator) {
+		e.serialBase = serial
+	}
+}
+
+// WithVersions sets the firmware versions of simulated Yubikeys, assigned round-robin.
+func WithVersions(versions ...ykman.Version) Option {
+	return func(e *Enumerator) {
+		if len(versions) > 0 {
+			e.versions = versions
+		}
+	}
+}
+
+// WithVendor makes the simulated devices generic FIDO authenticators of another vendor,
+// which can't be rebooted just like the real ones.
+func WithVendor(vendor string) Option {
+	return func(e *Enumerator) {
+		e.vendor = vendor
+	}
+}
+
+func WithFormFactor(formFactor ykman.FormFactor) Option {
+	return func(e *Enumerator) {
+		e.formFactor = formFactor
+	}
+}
+
+// WithHubLocation sets the USB location of the hub every simulated Yubikey is plugged into.
+func WithHubLocation(location string) Option {
+	return func(e *Enumerator) {
+		e.hubLocation = location
+	}
+}
+
+// WithShutdownDelay sets how long a Yubikey keeps answering under its old path after the reboot command.
+func WithShutdownDelay(delay time.Duration) Option {
+	return func(e *Enumerator) {
+		e.shutdownDelay = delay
+	}
+}
+
+// WithRebootLatency sets how long a rebooted Yubikey stays off the bus.
+func WithRebootLatency(latency time.Duration) Option {
+	return func(e *Enumerator) {
+		e.rebootLatency = latency
+	}
+}
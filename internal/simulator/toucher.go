@@ -0,0 +1,79 @@
+package simulator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/buglloc/yubictld/internal/touchctl"
+)
+
+var _ touchctl.Toucher = (*Toucher)(nil)
+
+type Touch struct {
+	Port     int
+	Delay    time.Duration
+	Duration time.Duration
+	At       time.Time
+}
+
+// Toucher is a fake toucher that records every touch instead of actuating anything.
+type Toucher struct {
+	location string
+	mu       sync.Mutex
+	touches  []Touch
+	attempts int
+	failure  error
+}
+
+func NewToucher(location string) *Toucher {
+	return &Toucher{
+		location: location,
+	}
+}
+
+func (t *Toucher) Touch(port int, delay time.Duration, duration time.Duration) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.attempts++
+	if t.failure != nil {
+		return t.failure
+	}
+
+	t.touches = append(t.touches, Touch{
+		Port:     port,
+		Delay:    delay,
+		Duration: duration,
+		At:       time.Now(),
+	})
+	return nil
+}
+
+func (t *Toucher) Location() string {
+	return t.location
+}
+
+// Fail makes every following touch fail with err, nil err restores normal behavior.
+func (t *Toucher) Fail(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.failure = err
+}
+
+func (t *Toucher) Touches() []Touch {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	out := make([]Touch, len(t.touches))
+	copy(out, t.touches)
+	return out
+}
+
+// Attempts returns how many touches were requested, failed ones included.
+func (t *Toucher) Attempts() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.attempts
+}
@@ -0,0 +1,131 @@
+package touchctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	MaxGesturePresses  = 16
+	MaxGestureDuration = time.Minute
+)
+
+// Press is a single press: released for Delay since the previous press, then held for Duration.
+type Press struct {
+	Delay    time.Duration
+	Duration time.Duration
+}
+
+// Gesture is a sequence of presses performed back to back.
+type Gesture []Press
+
+const (
+	GesturePresetShort        = "short"
+	GesturePresetLongOTPSlot2 = "long-otp-slot2"
+	GesturePresetDouble       = "double"
+)
+
+var GesturePresets = map[string]Gesture{
+	GesturePresetShort: {
+		{Duration: 200 * time.Millisecond},
+	},
+	// OTP slot 2 fires when held for 2.5-5s
+	GesturePresetLongOTPSlot2: {
+		{Duration: 3 * time.Second},
+	},
+	GesturePresetDouble: {
+		{Duration: 200 * time.Millisecond},
+		{Delay: 500 * time.Millisecond, Duration: 200 * time.Millisecond},
+	},
+}
+
+func Preset(name string) (Gesture, error) {
+	g, ok := GesturePresets[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown touch preset: %s", name)
+	}
+
+	return g, nil
+}
+
+func (g Gesture) Validate() error {
+	if len(g) == 0 {
+		return errors.New("empty gesture")
+	}
+
+	if len(g) > MaxGesturePresses {
+		return fmt.Errorf("too many presses: %d > %d", len(g), MaxGesturePresses)
+	}
+
+	for i, p := range g {
+		if p.Delay < 0 || p.Duration < 0 {
+			return fmt.Errorf("press %d: negative timing", i)
+		}
+	}
+
+	if total := g.Duration(); total > MaxGestureDuration {
+		return fmt.Errorf("gesture is too long: %s > %s", total, MaxGestureDuration)
+	}
+
+	return nil
+}
+
+// Duration returns how long the whole gesture takes.
+func (g Gesture) Duration() time.Duration {
+	var total time.Duration
+	for _, p := range g {
+		total += p.Delay + p.Duration
+	}
+
+	return total
+}
+
+// Perform performs the gesture on the toucher port. Presses are scheduled against the gesture start,
+// so neither toucher latency nor touchers returning before the release shift the following presses.
+// Stops between presses when ctx is done, and in the middle of a press if the toucher is a ContextToucher.
+// A GestureToucher gets the whole gesture instead.
+func Perform(ctx context.Context, t Toucher, port int, g Gesture) error {
+	if gt, ok := t.(GestureToucher); ok {
+		return gt.TouchGesture(ctx, port, g)
+	}
+
+	startedAt := time.Now()
+	var offset time.Duration
+	for i, p := range g {
+		offset += p.Delay
+		if err := sleepUntil(ctx, startedAt.Add(offset)); err != nil {
+			return err
+		}
+
+		if err := TouchContext(ctx, t, port, 0, p.Duration); err != nil {
+			return fmt.Errorf("press %d: %w", i, err)
+		}
+
+		offset += p.Duration
+	}
+
+	return sleepUntil(ctx, startedAt.Add(offset))
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	return sleepUntil(ctx, time.Now().Add(d))
+}
+
+func sleepUntil(ctx context.Context, deadline time.Time) error {
+	d := time.Until(deadline)
+	if d <= 0 {
+		return ctx.Err()
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,122 @@
+package touchctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+const DefaultGPIOChip = "/dev/gpiochip0"
+
+var _ Toucher = (*GPIO)(nil)
+
+// GPIOLines drives output lines requested from a gpiochip.
+type GPIOLines interface {
+	Set(offset int, active bool) error
+	Close() error
+}
+
+// GPIO touches Yubikeys with solenoids or touch relays wired to gpiochip lines.
+type GPIO struct {
+	lines    GPIOLines
+	offsets  map[int]int
+	location string
+	mu       sync.Mutex
+}
+
+func NewGPIO(opts ...GPIOOption) (*GPIO, error) {
+	chip := DefaultGPIOChip
+	offsets := make(map[int]int)
+	var activeLow bool
+	var location string
+	var lines GPIOLines
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case optGPIOChip:
+			if v.path != "" {
+				chip = v.path
+			}
+
+		case optGPIOLine:
+			offsets[v.port] = v.offset
+
+		case optGPIOActiveLow:
+			activeLow = v.activeLow
+
+		case optGPIOLocation:
+			location = v.location
+
+		case optGPIODriver:
+			lines = v.driver
+
+		default:
+			return nil, fmt.Errorf("invalid gpio option: %T", opt)
+		}
+	}
+
+	if len(offsets) == 0 {
+		return nil, errors.New("no gpio lines configured")
+	}
+
+	if lines == nil {
+		requested := make([]int, 0, len(offsets))
+		for _, offset := range offsets {
+			requested = append(requested, offset)
+		}
+		slices.Sort(requested)
+
+		var err error
+		lines, err = openGPIOChip(chip, slices.Compact(requested), activeLow)
+		if err != nil {
+			return nil, fmt.Errorf("request lines from %s: %w", chip, err)
+		}
+
+		if location == "" {
+			location = sysfsUSBLocation(filepath.Join("/sys/bus/gpio/devices", filepath.Base(chip)))
+		}
+	}
+
+	return &GPIO{
+		lines:    lines,
+		offsets:  offsets,
+		location: location,
+	}, nil
+}
+
+func (g *GPIO) Location() string {
+	return g.location
+}
+
+func (g *GPIO) Touch(port int, delay time.Duration, duration time.Duration) error {
+	return g.TouchContext(context.Background(), port, delay, duration)
+}
+
+func (g *GPIO) TouchContext(ctx context.Context, port int, delay time.Duration, duration time.Duration) error {
+	offset, ok := g.offsets[port]
+	if !ok {
+		return fmt.Errorf("no gpio line for port %d", port)
+	}
+
+	return press(ctx, delay, duration, func(active bool) error {
+		if err := g.set(offset, active); err != nil {
+			return fmt.Errorf("line %d: %w", offset, err)
+		}
+
+		return nil
+	})
+}
+
+func (g *GPIO) Close() error {
+	return g.lines.Close()
+}
+
+func (g *GPIO) set(offset int, active bool) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.lines.Set(offset, active)
+}
@@ -0,0 +1,65 @@
+package touchctl
+
+type GPIOOption interface {
+	isGPIOOption()
+}
+
+type optGPIOChip struct {
+	GPIOOption
+	path string
+}
+
+func GPIOWithChip(path string) GPIOOption {
+	return optGPIOChip{
+		path: path,
+	}
+}
+
+type optGPIOLine struct {
+	GPIOOption
+	port   int
+	offset int
+}
+
+// GPIOWithLine maps the toucher port to the gpiochip line offset.
+func GPIOWithLine(port int, offset int) GPIOOption {
+	return optGPIOLine{
+		port:   port,
+		offset: offset,
+	}
+}
+
+type optGPIOActiveLow struct {
+	GPIOOption
+	activeLow bool
+}
+
+func GPIOWithActiveLow(activeLow bool) GPIOOption {
+	return optGPIOActiveLow{
+		activeLow: activeLow,
+	}
+}
+
+type optGPIOLocation struct {
+	GPIOOption
+	location string
+}
+
+// GPIOWithLocation overrides the USB location of the toucher, e.g. for a hub wired to the GPIO rig.
+func GPIOWithLocation(location string) GPIOOption {
+	return optGPIOLocation{
+		location: location,
+	}
+}
+
+type optGPIODriver struct {
+	GPIOOption
+	driver GPIOLines
+}
+
+// GPIOWithDriver replaces the gpiochip character device with the given line driver.
+func GPIOWithDriver(driver GPIOLines) GPIOOption {
+	return optGPIODriver{
+		driver: driver,
+	}
+}
@@ -0,0 +1,100 @@
+package touchctl_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/touchctl"
+)
+
+type fakeLines struct {
+	mu     sync.Mutex
+	events []string
+	closed bool
+}
+
+func (l *fakeLines) Set(offset int, active bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.events = append(l.events, fmt.Sprintf("%d=%t", offset, active))
+	return nil
+}
+
+func (l *fakeLines) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.closed = true
+	return nil
+}
+
+func (l *fakeLines) Events() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string(nil), l.events...)
+}
+
+func newFakeGPIO(t *testing.T) (*touchctl.GPIO, *fakeLines) {
+	t.Helper()
+
+	lines := &fakeLines{}
+	g, err := touchctl.NewGPIO(
+		touchctl.GPIOWithDriver(lines),
+		touchctl.GPIOWithLine(1, 17),
+		touchctl.GPIOWithLine(2, 27),
+		touchctl.GPIOWithLocation("1-2"),
+	)
+	require.NoError(t, err)
+
+	return g, lines
+}
+
+func TestGPIO_Touch(t *testing.T) {
+	g, lines := newFakeGPIO(t)
+	require.Equal(t, "1-2", g.Location())
+
+	require.NoError(t, g.Touch(2, 0, 10*time.Millisecond))
+	require.NoError(t, g.Touch(1, 0, 10*time.Millisecond))
+	require.Equal(t, []string{"27=true", "27=false", "17=true", "17=false"}, lines.Events())
+
+	require.Error(t, g.Touch(3, 0, 0))
+
+	require.NoError(t, g.Close())
+	require.True(t, lines.closed)
+}
+
+func TestGPIO_NoLines(t *testing.T) {
+	_, err := touchctl.NewGPIO(touchctl.GPIOWithDriver(&fakeLines{}))
+	require.Error(t, err)
+}
+
+func TestGPIO_CancelReleasesLine(t *testing.T) {
+	g, lines := newFakeGPIO(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	startedAt := time.Now()
+	err := touchctl.Perform(ctx, g, 1, touchctl.Gesture{{Duration: time.Minute}})
+	require.ErrorIs(t, err, context.Canceled)
+	require.Less(t, time.Since(startedAt), 5*time.Second)
+	require.Equal(t, []string{"17=true", "17=false"}, lines.Events())
+}
+
+func TestGPIO_CancelBeforePress(t *testing.T) {
+	g, lines := newFakeGPIO(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := g.TouchContext(ctx, 1, time.Minute, time.Second)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, lines.Events())
+}
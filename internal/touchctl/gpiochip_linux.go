@@ -0,0 +1,116 @@
+//go:build linux
+
+package touchctl
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// gpio v2 character device uAPI, see linux/gpio.h
+const (
+	gpioV2LinesMax           = 64
+	gpioV2LineNumAttrsMax    = 10
+	gpioV2LineFlagActiveLow  = 1 << 1
+	gpioV2LineFlagOutput     = 1 << 3
+	gpioV2GetLineIoctl       = 0xC250B407
+	gpioV2LineSetValuesIoctl = 0xC010B40F
+)
+
+type gpioV2LineConfigAttribute struct {
+	id      uint32
+	padding uint32
+	value   uint64
+	mask    uint64
+}
+
+type gpioV2LineConfig struct {
+	flags    uint64
+	numAttrs uint32
+	padding  [5]uint32
+	attrs    [gpioV2LineNumAttrsMax]gpioV2LineConfigAttribute
+}
+
+type gpioV2LineRequest struct {
+	offsets         [gpioV2LinesMax]uint32
+	consumer        [32]byte
+	config          gpioV2LineConfig
+	numLines        uint32
+	eventBufferSize uint32
+	padding         [5]uint32
+	fd              int32
+}
+
+type gpioV2LineValues struct {
+	bits uint64
+	mask uint64
+}
+
+type gpioChipLines struct {
+	f   *os.File
+	idx map[int]uint
+}
+
+func openGPIOChip(path string, offsets []int, activeLow bool) (GPIOLines, error) {
+	if len(offsets) > gpioV2LinesMax {
+		return nil, fmt.Errorf("too many lines: %d > %d", len(offsets), gpioV2LinesMax)
+	}
+
+	chip, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = chip.Close() }()
+
+	req := gpioV2LineRequest{
+		numLines: uint32(len(offsets)),
+	}
+	copy(req.consumer[:], "yubictld")
+	req.config.flags = gpioV2LineFlagOutput
+	if activeLow {
+		req.config.flags |= gpioV2LineFlagActiveLow
+	}
+
+	idx := make(map[int]uint, len(offsets))
+	for i, offset := range offsets {
+		req.offsets[i] = uint32(offset)
+		idx[offset] = uint(i)
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, chip.Fd(), gpioV2GetLineIoctl, uintptr(unsafe.Pointer(&req)))
+	if errno != 0 {
+		return nil, fmt.Errorf("GPIO_V2_GET_LINE: %w", errno)
+	}
+
+	return &gpioChipLines{
+		f:   os.NewFile(uintptr(req.fd), path),
+		idx: idx,
+	}, nil
+}
+
+func (l *gpioChipLines) Set(offset int, active bool) error {
+	i, ok := l.idx[offset]
+	if !ok {
+		return fmt.Errorf("line %d was not requested", offset)
+	}
+
+	values := gpioV2LineValues{
+		mask: 1 << i,
+	}
+	if active {
+		values.bits = 1 << i
+	}
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, l.f.Fd(), gpioV2LineSetValuesIoctl, uintptr(unsafe.Pointer(&values)))
+	if errno != 0 {
+		return fmt.Errorf("GPIO_V2_LINE_SET_VALUES: %w", errno)
+	}
+
+	return nil
+}
+
+func (l *gpioChipLines) Close() error {
+	return l.f.Close()
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package touchctl
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func openGPIOChip(_ string, _ []int, _ bool) (GPIOLines, error) {
+	return nil, fmt.Errorf("gpiochip is not supported on %s", runtime.GOOS)
+}
@@ -0,0 +1,183 @@
+package touchctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/buglloc/usbhid"
+)
+
+const (
+	HIDRelayVID = 0x16c0
+	HIDRelayPID = 0x05df
+
+	hidRelayProduct    = "USBRelay"
+	hidRelayCmdOn      = 0xFF
+	hidRelayCmdOff     = 0xFD
+	hidRelaySerialSize = 5
+)
+
+var _ Toucher = (*HIDRelay)(nil)
+
+// HIDRelay touches Yubikeys with dcttech/ucreatefun HID relay modules (USBRelayN).
+type HIDRelay struct {
+	dev      *usbhid.Device
+	serial   string
+	channels map[int]byte
+	mu       sync.Mutex
+}
+
+func NewHIDRelay(opts ...HIDRelayOption) (*HIDRelay, error) {
+	var serial string
+	channels := make(map[int]byte)
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case optHIDRelaySerial:
+			serial = v.serial
+
+		case optHIDRelayChannel:
+			if v.channel < 1 || v.channel > 8 {
+				return nil, fmt.Errorf("invalid relay channel for port %d: %d", v.port, v.channel)
+			}
+			channels[v.port] = byte(v.channel)
+
+		default:
+			return nil, fmt.Errorf("invalid hidrelay option: %T", opt)
+		}
+	}
+
+	if len(channels) == 0 {
+		return nil, errors.New("no relay channels configured")
+	}
+
+	dev, boardSerial, err := openHIDRelay(serial)
+	if err != nil {
+		return nil, err
+	}
+
+	// the command goes in the first byte of the report and the channel in the second one
+	if reportLen := dev.GetFeatureReportLength(); reportLen < 2 {
+		_ = dev.Close()
+		return nil, fmt.Errorf("board %s: feature report is too short: %d", boardSerial, reportLen)
+	}
+
+	numChannels, _ := strconv.Atoi(strings.TrimPrefix(dev.Product(), hidRelayProduct))
+	for port, ch := range channels {
+		if numChannels > 0 && int(ch) > numChannels {
+			_ = dev.Close()
+			return nil, fmt.Errorf("port %d: board %s has only %d channels", port, boardSerial, numChannels)
+		}
+	}
+
+	return &HIDRelay{
+		dev:      dev,
+		serial:   boardSerial,
+		channels: channels,
+	}, nil
+}
+
+func (r *HIDRelay) Serial() string {
+	return r.serial
+}
+
+func (r *HIDRelay) Location() string {
+	return r.dev.Location()
+}
+
+func (r *HIDRelay) Touch(port int, delay time.Duration, duration time.Duration) error {
+	return r.TouchContext(context.Background(), port, delay, duration)
+}
+
+func (r *HIDRelay) TouchContext(ctx context.Context, port int, delay time.Duration, duration time.Duration) error {
+	ch, ok := r.channels[port]
+	if !ok {
+		return fmt.Errorf("no relay channel for port %d", port)
+	}
+
+	return press(ctx, delay, duration, func(on bool) error {
+		if err := r.set(ch, on); err != nil {
+			return fmt.Errorf("relay %d: %w", ch, err)
+		}
+
+		return nil
+	})
+}
+
+func (r *HIDRelay) Close() error {
+	return r.dev.Close()
+}
+
+func (r *HIDRelay) set(ch byte, on bool) error {
+	cmd := byte(hidRelayCmdOff)
+	if on {
+		cmd = hidRelayCmdOn
+	}
+
+	report := make([]byte, r.dev.GetFeatureReportLength())
+	report[0] = cmd
+	report[1] = ch
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.dev.SetFeatureReport(0, report)
+}
+
+func openHIDRelay(serial string) (*usbhid.Device, string, error) {
+	devices, err := usbhid.Enumerate(
+		usbhid.WithVidFilter(HIDRelayVID),
+		usbhid.WithPidFilter(HIDRelayPID),
+		usbhid.WithDeviceFilterFunc(func(d *usbhid.Device) bool {
+			return strings.HasPrefix(d.Product(), hidRelayProduct)
+		}),
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("enumerate relay boards: %w", err)
+	}
+
+	var found []string
+	for _, dev := range devices {
+		if err := dev.Open(true); err != nil {
+			continue
+		}
+
+		boardSerial, err := hidRelaySerial(dev)
+		if err == nil && (serial == "" || boardSerial == serial) {
+			return dev, boardSerial, nil
+		}
+
+		found = append(found, boardSerial)
+		_ = dev.Close()
+	}
+
+	if serial == "" {
+		return nil, "", errors.New("no relay boards found")
+	}
+
+	return nil, "", fmt.Errorf("relay board %q not found, available: %s", serial, strings.Join(found, ", "))
+}
+
+// hidRelaySerial reads the board serial, stored in the first bytes of the feature report.
+func hidRelaySerial(dev *usbhid.Device) (string, error) {
+	report, err := dev.GetFeatureReport(0)
+	if err != nil {
+		return "", err
+	}
+
+	// some platforms keep the report id in front of the report
+	if len(report) > int(dev.GetFeatureReportLength()) {
+		report = report[1:]
+	}
+
+	if len(report) < hidRelaySerialSize {
+		return "", fmt.Errorf("short feature report: %d", len(report))
+	}
+
+	return string(bytes.TrimRight(report[:hidRelaySerialSize], "\x00")), nil
+}
@@ -0,0 +1,31 @@
+package touchctl
+
+type HIDRelayOption interface {
+	isHIDRelayOption()
+}
+
+type optHIDRelaySerial struct {
+	HIDRelayOption
+	serial string
+}
+
+// HIDRelayWithSerial selects the relay board by its serial, the first found board is used otherwise.
+func HIDRelayWithSerial(serial string) HIDRelayOption {
+	return optHIDRelaySerial{
+		serial: serial,
+	}
+}
+
+type optHIDRelayChannel struct {
+	HIDRelayOption
+	port    int
+	channel int
+}
+
+// HIDRelayWithChannel maps the toucher port to the relay channel (starting from 1).
+func HIDRelayWithChannel(port, channel int) HIDRelayOption {
+	return optHIDRelayChannel{
+		port:    port,
+		channel: channel,
+	}
+}
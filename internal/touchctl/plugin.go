@@ -0,0 +1,478 @@
+package touchctl
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	DefaultPluginTimeout        = 10 * time.Second
+	DefaultPluginHealthInterval = 30 * time.Second
+	DefaultPluginMinBackoff     = time.Second
+	DefaultPluginMaxBackoff     = time.Minute
+)
+
+var ErrPluginExited = errors.New("plugin exited")
+
+var _ Toucher = (*Plugin)(nil)
+var _ ContextToucher = (*Plugin)(nil)
+
+// Plugin delegates touches to an external executable speaking the plugin protocol,
+// restarting it with a backoff when it crashes or fails health checks.
+type Plugin struct {
+	path           string
+	args           []string
+	timeout        time.Duration
+	healthInterval time.Duration
+	minBackoff     time.Duration
+	maxBackoff     time.Duration
+	nextID         atomic.Uint64
+	mu             sync.Mutex
+	proc           *pluginProc
+	hello          PluginHelloRsp
+	location       string
+	closed         chan struct{}
+	closeOnce      sync.Once
+	supervised     chan struct{}
+}
+
+func NewPlugin(path string, opts ...PluginOption) (*Plugin, error) {
+	p := &Plugin{
+		path:           path,
+		timeout:        DefaultPluginTimeout,
+		healthInterval: DefaultPluginHealthInterval,
+		minBackoff:     DefaultPluginMinBackoff,
+		maxBackoff:     DefaultPluginMaxBackoff,
+		closed:         make(chan struct{}),
+		supervised:     make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case optPluginArgs:
+			p.args = v.args
+
+		case optPluginTimeout:
+			if v.timeout > 0 {
+				p.timeout = v.timeout
+			}
+
+		case optPluginHealthInterval:
+			if v.interval != 0 {
+				p.healthInterval = v.interval
+			}
+
+		case optPluginBackoff:
+			if v.min > 0 {
+				p.minBackoff = v.min
+			}
+
+			if v.max > 0 {
+				p.maxBackoff = v.max
+			}
+
+		default:
+			return nil, fmt.Errorf("invalid plugin option: %T", opt)
+		}
+	}
+
+	if path == "" {
+		return nil, errors.New("no plugin executable configured")
+	}
+
+	if err := p.start(); err != nil {
+		return nil, err
+	}
+
+	go p.supervise()
+	return p, nil
+}
+
+func (p *Plugin) Location() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.location
+}
+
+// Hello returns what the running plugin reported about itself.
+func (p *Plugin) Hello() PluginHelloRsp {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.hello
+}
+
+func (p *Plugin) Touch(port int, delay time.Duration, duration time.Duration) error {
+	return p.TouchContext(context.Background(), port, delay, duration)
+}
+
+// TouchContext stops waiting for the touch once ctx is done and asks the plugin to release the button,
+// if it is able to.
+func (p *Plugin) TouchContext(ctx context.Context, port int, delay time.Duration, duration time.Duration) error {
+	touchCtx, cancel := context.WithTimeout(ctx, delay+duration+p.timeout)
+	defer cancel()
+
+	p.mu.Lock()
+	proc := p.proc
+	hello := p.hello
+	p.mu.Unlock()
+
+	id := p.nextID.Add(1)
+	err := proc.call(touchCtx, pluginReq{
+		ID:     id,
+		Method: PluginMethodTouch,
+		Params: PluginTouchReq{
+			Port:       port,
+			DelayMs:    delay.Milliseconds(),
+			DurationMs: duration.Milliseconds(),
+		},
+	}, nil)
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+
+	if hello.can(PluginMethodCancel) {
+		cancelCtx, cancelCancel := context.WithTimeout(context.Background(), p.timeout)
+		defer cancelCancel()
+
+		err := proc.call(cancelCtx, pluginReq{
+			ID:     p.nextID.Add(1),
+			Method: PluginMethodCancel,
+			Params: PluginCancelReq{ID: id},
+		}, nil)
+		if err != nil {
+			log.Warn().Err(err).Str("plugin", p.path).Uint64("touch_id", id).Msg("cancel plugin touch")
+		}
+	}
+
+	return ctx.Err()
+}
+
+// Health asks the plugin whether its rig is operational.
+func (p *Plugin) Health(ctx context.Context) error {
+	if !p.Hello().can(PluginMethodHealth) {
+		return nil
+	}
+
+	return p.call(ctx, PluginMethodHealth, nil, nil)
+}
+
+func (p *Plugin) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+	})
+
+	// the supervisor must not start a new child behind our back
+	<-p.supervised
+
+	p.mu.Lock()
+	proc := p.proc
+	p.mu.Unlock()
+
+	proc.kill()
+	<-proc.done
+	return nil
+}
+
+func (p *Plugin) call(ctx context.Context, method string, params any, out any) error {
+	p.mu.Lock()
+	proc := p.proc
+	p.mu.Unlock()
+
+	return proc.call(ctx, pluginReq{
+		ID:     p.nextID.Add(1),
+		Method: method,
+		Params: params,
+	}, out)
+}
+
+func (p *Plugin) supervise() {
+	defer close(p.supervised)
+
+	backoff := p.minBackoff
+
+	var healthC <-chan time.Time
+	if p.healthInterval > 0 {
+		ticker := time.NewTicker(p.healthInterval)
+		defer ticker.Stop()
+		healthC = ticker.C
+	}
+
+	for {
+		p.mu.Lock()
+		proc := p.proc
+		p.mu.Unlock()
+
+		select {
+		case <-p.closed:
+			return
+
+		case <-healthC:
+			ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+			err := p.Health(ctx)
+			cancel()
+			if err != nil {
+				log.Error().Err(err).Str("plugin", p.path).Msg("plugin is unhealthy, restarting")
+				proc.kill()
+			}
+			continue
+
+		case <-proc.done:
+		}
+
+		log.Warn().Err(proc.err).Str("plugin", p.path).Msg("plugin exited")
+		if time.Since(proc.startedAt) > p.maxBackoff {
+			backoff = p.minBackoff
+		}
+
+		for {
+			select {
+			case <-p.closed:
+				return
+			case <-time.After(backoff):
+			}
+
+			backoff = min(backoff*2, p.maxBackoff)
+			if err := p.start(); err != nil {
+				log.Error().Err(err).Str("plugin", p.path).Dur("backoff", backoff).Msg("restart plugin")
+				continue
+			}
+
+			break
+		}
+	}
+}
+
+func (p *Plugin) start() error {
+	proc, err := startPluginProc(p.path, p.args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+	defer cancel()
+
+	var hello PluginHelloRsp
+	err = proc.call(ctx, pluginReq{
+		ID:     p.nextID.Add(1),
+		Method: PluginMethodHello,
+		Params: PluginHelloReq{Protocol: PluginProtocolVersion},
+	}, &hello)
+	if err != nil {
+		proc.kill()
+		return fmt.Errorf("plugin hello: %w", err)
+	}
+
+	if hello.Protocol != PluginProtocolVersion {
+		proc.kill()
+		return fmt.Errorf("unsupported plugin protocol: %d", hello.Protocol)
+	}
+
+	var location PluginLocationRsp
+	if hello.can(PluginMethodLocation) {
+		err = proc.call(ctx, pluginReq{
+			ID:     p.nextID.Add(1),
+			Method: PluginMethodLocation,
+		}, &location)
+		if err != nil {
+			proc.kill()
+			return fmt.Errorf("plugin location: %w", err)
+		}
+	}
+
+	p.mu.Lock()
+	p.proc = proc
+	p.hello = hello
+	p.location = location.Location
+	p.mu.Unlock()
+
+	log.Info().
+		Str("plugin", p.path).
+		Str("name", hello.Name).
+		Str("version", hello.Version).
+		Str("location", location.Location).
+		Msg("plugin started")
+	return nil
+}
+
+func (h PluginHelloRsp) can(method string) bool {
+	return slices.Contains(h.Capabilities, method)
+}
+
+type pluginProc struct {
+	cmd        *exec.Cmd
+	stdin      *os.File
+	startedAt  time.Time
+	writeMu    sync.Mutex
+	mu         sync.Mutex
+	pending    map[uint64]chan pluginRsp
+	stderrDone chan struct{}
+	done       chan struct{}
+	err        error
+}
+
+func startPluginProc(path string, args []string) (*pluginProc, error) {
+	cmd := exec.Command(path, args...)
+	// our own pipe instead of StdinPipe, as writes to a hung plugin need a deadline
+	stdinR, stdin, err := os.Pipe()
+	if err != nil {
+		return nil, fmt.Errorf("stdin pipe: %w", err)
+	}
+	defer func() { _ = stdinR.Close() }()
+	cmd.Stdin = stdinR
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		_ = stdin.Close()
+		return nil, fmt.Errorf("stdout pipe: %w", err)
+	}
+
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		_ = stdin.Close()
+		return nil, fmt.Errorf("stderr pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		_ = stdin.Close()
+		return nil, fmt.Errorf("start plugin %s: %w", path, err)
+	}
+
+	proc := &pluginProc{
+		cmd:        cmd,
+		stdin:      stdin,
+		startedAt:  time.Now(),
+		pending:    make(map[uint64]chan pluginRsp),
+		stderrDone: make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	go func() {
+		defer close(proc.stderrDone)
+
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Info().Str("plugin", path).Msg(scanner.Text())
+		}
+	}()
+
+	go proc.readLoop(stdout)
+	return proc, nil
+}
+
+func (pp *pluginProc) readLoop(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		var rsp pluginRsp
+		if err := json.Unmarshal(scanner.Bytes(), &rsp); err != nil {
+			log.Warn().Err(err).Str("line", scanner.Text()).Msg("invalid plugin response")
+			continue
+		}
+
+		pp.mu.Lock()
+		ch, ok := pp.pending[rsp.ID]
+		delete(pp.pending, rsp.ID)
+		pp.mu.Unlock()
+
+		if ok {
+			ch <- rsp
+		}
+	}
+
+	// Wait closes the pipes, so every read from them must be done by then
+	<-pp.stderrDone
+	err := pp.cmd.Wait()
+	_ = pp.stdin.Close()
+
+	pp.mu.Lock()
+	pp.err = err
+	pp.pending = nil
+	pp.mu.Unlock()
+	close(pp.done)
+}
+
+func (pp *pluginProc) call(ctx context.Context, req pluginReq, out any) error {
+	line, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("marshal request: %w", err)
+	}
+
+	ch := make(chan pluginRsp, 1)
+	pp.mu.Lock()
+	if pp.pending == nil {
+		pp.mu.Unlock()
+		return ErrPluginExited
+	}
+
+	pp.pending[req.ID] = ch
+	pp.mu.Unlock()
+
+	defer func() {
+		pp.mu.Lock()
+		delete(pp.pending, req.ID)
+		pp.mu.Unlock()
+	}()
+
+	if err := pp.write(ctx, append(line, '\n')); err != nil {
+		return fmt.Errorf("send %s request: %w", req.Method, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("%s: %w", req.Method, ctx.Err())
+	case <-pp.done:
+		return ErrPluginExited
+	case rsp := <-ch:
+		if rsp.Error != "" {
+			return fmt.Errorf("%s: %s", req.Method, rsp.Error)
+		}
+
+		if out != nil && len(rsp.Result) > 0 {
+			if err := json.Unmarshal(rsp.Result, out); err != nil {
+				return fmt.Errorf("parse %s result: %w", req.Method, err)
+			}
+		}
+
+		return nil
+	}
+}
+
+// write sends the request line, giving up at the ctx deadline if the plugin doesn't read its stdin.
+func (pp *pluginProc) write(ctx context.Context, line []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	pp.writeMu.Lock()
+	defer pp.writeMu.Unlock()
+
+	deadline, _ := ctx.Deadline()
+	if err := pp.stdin.SetWriteDeadline(deadline); err != nil {
+		return err
+	}
+
+	if _, err := pp.stdin.Write(line); err != nil {
+		// a partially written line breaks the stream, the supervisor restarts the plugin
+		pp.kill()
+		return err
+	}
+
+	return nil
+}
+
+func (pp *pluginProc) kill() {
+	_ = pp.cmd.Process.Kill()
+}
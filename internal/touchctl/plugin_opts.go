@@ -0,0 +1,56 @@
+package touchctl
+
+import "time"
+
+type PluginOption interface {
+	isPluginOption()
+}
+
+type optPluginArgs struct {
+	PluginOption
+	args []string
+}
+
+func PluginWithArgs(args ...string) PluginOption {
+	return optPluginArgs{
+		args: args,
+	}
+}
+
+type optPluginTimeout struct {
+	PluginOption
+	timeout time.Duration
+}
+
+// PluginWithTimeout sets how long the plugin may take to answer, on top of the touch delay and duration.
+func PluginWithTimeout(timeout time.Duration) PluginOption {
+	return optPluginTimeout{
+		timeout: timeout,
+	}
+}
+
+type optPluginHealthInterval struct {
+	PluginOption
+	interval time.Duration
+}
+
+// PluginWithHealthInterval sets how often the plugin health is checked, a negative interval disables the checks.
+func PluginWithHealthInterval(interval time.Duration) PluginOption {
+	return optPluginHealthInterval{
+		interval: interval,
+	}
+}
+
+type optPluginBackoff struct {
+	PluginOption
+	min time.Duration
+	max time.Duration
+}
+
+// PluginWithBackoff sets the bounds of the exponential backoff between plugin restarts.
+func PluginWithBackoff(min, max time.Duration) PluginOption {
+	return optPluginBackoff{
+		min: min,
+		max: max,
+	}
+}
@@ -0,0 +1,72 @@
+package touchctl
+
+import "encoding/json"
+
+// Plugin protocol.
+//
+// The daemon starts the plugin executable and talks to it with line-delimited JSON:
+// one request per line on the plugin stdin, one response per line on its stdout.
+// Responses carry the request id and may come out of order. The plugin stderr is logged.
+//
+//	-> {"id":1,"method":"hello","params":{"protocol":1}}
+//	<- {"id":1,"result":{"name":"robot","version":"0.1","protocol":1,"capabilities":["location","health"]}}
+//	-> {"id":2,"method":"location"}
+//	<- {"id":2,"result":{"location":"1-1"}}
+//	-> {"id":3,"method":"touch","params":{"port":2,"delay_ms":0,"duration_ms":500}}
+//	<- {"id":3,"result":{}}
+//	-> {"id":4,"method":"health"}
+//	<- {"id":4,"error":"arm is stuck"}
+//	-> {"id":5,"method":"touch","params":{"port":2,"delay_ms":0,"duration_ms":5000}}
+//	-> {"id":6,"method":"cancel","params":{"id":5}}
+//	<- {"id":6,"result":{}}
+//	<- {"id":5,"error":"canceled"}
+//
+// A touch response must be sent once the touch is done. Cancel asks to release the button
+// of the touch in progress right away, so requests must be served concurrently to support it.
+// Methods not listed in the hello capabilities (other than hello and touch) are never called.
+const PluginProtocolVersion = 1
+
+const (
+	PluginMethodHello    = "hello"
+	PluginMethodLocation = "location"
+	PluginMethodTouch    = "touch"
+	PluginMethodHealth   = "health"
+	PluginMethodCancel   = "cancel"
+)
+
+type pluginReq struct {
+	ID     uint64 `json:"id"`
+	Method string `json:"method"`
+	Params any    `json:"params,omitempty"`
+}
+
+type pluginRsp struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+type PluginHelloReq struct {
+	Protocol int `json:"protocol"`
+}
+
+type PluginHelloRsp struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	Protocol     int      `json:"protocol"`
+	Capabilities []string `json:"capabilities"`
+}
+
+type PluginLocationRsp struct {
+	Location string `json:"location"`
+}
+
+type PluginCancelReq struct {
+	ID uint64 `json:"id"`
+}
+
+type PluginTouchReq struct {
+	Port       int   `json:"port"`
+	DelayMs    int64 `json:"delay_ms"`
+	DurationMs int64 `json:"duration_ms"`
+}
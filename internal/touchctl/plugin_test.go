@@ -0,0 +1,182 @@
+package touchctl_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/touchctl"
+)
+
+// fakePluginEnv makes the test binary act as a touch plugin instead of running the tests.
+const fakePluginEnv = "YUBICTLD_FAKE_PLUGIN"
+
+// fakePluginCancelsEnv names the file the fake plugin records the canceled touch to.
+const fakePluginCancelsEnv = "YUBICTLD_FAKE_PLUGIN_CANCELS"
+
+// crashPort makes the fake plugin exit instead of touching.
+const crashPort = 13
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakePluginEnv) != "" {
+		runFakePlugin()
+		return
+	}
+
+	os.Exit(m.Run())
+}
+
+func runFakePlugin() {
+	type request struct {
+		ID     uint64          `json:"id"`
+		Method string          `json:"method"`
+		Params json.RawMessage `json:"params"`
+	}
+
+	var mu sync.Mutex
+	enc := json.NewEncoder(os.Stdout)
+	send := func(rsp map[string]any) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		_ = enc.Encode(rsp)
+	}
+	reply := func(id uint64, result any) {
+		send(map[string]any{"id": id, "result": result})
+	}
+
+	touches := make(map[uint64]chan struct{})
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			os.Exit(2)
+		}
+
+		switch req.Method {
+		case touchctl.PluginMethodHello:
+			reply(req.ID, touchctl.PluginHelloRsp{
+				Name:     "fake",
+				Version:  "1.0",
+				Protocol: touchctl.PluginProtocolVersion,
+				Capabilities: []string{
+					touchctl.PluginMethodLocation,
+					touchctl.PluginMethodHealth,
+					touchctl.PluginMethodCancel,
+				},
+			})
+
+		case touchctl.PluginMethodLocation:
+			reply(req.ID, touchctl.PluginLocationRsp{Location: "3-1"})
+
+		case touchctl.PluginMethodHealth:
+			reply(req.ID, struct{}{})
+
+		case touchctl.PluginMethodTouch:
+			var params touchctl.PluginTouchReq
+			_ = json.Unmarshal(req.Params, &params)
+			if params.Port == crashPort {
+				fmt.Fprintln(os.Stderr, "arm crashed")
+				os.Exit(1)
+			}
+
+			canceled := make(chan struct{})
+			mu.Lock()
+			touches[req.ID] = canceled
+			mu.Unlock()
+
+			go func(id uint64) {
+				select {
+				case <-time.After(time.Duration(params.DelayMs+params.DurationMs) * time.Millisecond):
+					reply(id, struct{}{})
+				case <-canceled:
+					send(map[string]any{"id": id, "error": "canceled"})
+				}
+			}(req.ID)
+
+		case touchctl.PluginMethodCancel:
+			var params touchctl.PluginCancelReq
+			_ = json.Unmarshal(req.Params, &params)
+
+			mu.Lock()
+			if canceled, ok := touches[params.ID]; ok {
+				close(canceled)
+				delete(touches, params.ID)
+			}
+			mu.Unlock()
+
+			if path := os.Getenv(fakePluginCancelsEnv); path != "" {
+				_ = os.WriteFile(path, []byte(strconv.FormatUint(params.ID, 10)), 0o644)
+			}
+			reply(req.ID, struct{}{})
+		}
+	}
+}
+
+func newFakePlugin(t *testing.T, opts ...touchctl.PluginOption) *touchctl.Plugin {
+	t.Helper()
+
+	t.Setenv(fakePluginEnv, "1")
+	p, err := touchctl.NewPlugin(os.Args[0], opts...)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = p.Close()
+	})
+
+	return p
+}
+
+func TestPlugin_Touch(t *testing.T) {
+	p := newFakePlugin(t)
+
+	require.Equal(t, "fake", p.Hello().Name)
+	require.Equal(t, "3-1", p.Location())
+	require.NoError(t, p.Touch(1, 0, 10*time.Millisecond))
+	require.NoError(t, p.Health(t.Context()))
+}
+
+func TestPlugin_RestartsAfterCrash(t *testing.T) {
+	p := newFakePlugin(t, touchctl.PluginWithBackoff(10*time.Millisecond, 100*time.Millisecond))
+
+	require.ErrorIs(t, p.Touch(crashPort, 0, 0), touchctl.ErrPluginExited)
+	require.Eventually(t, func() bool {
+		return p.Touch(1, 0, 0) == nil
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func TestPlugin_CloseStopsRestarts(t *testing.T) {
+	p := newFakePlugin(t, touchctl.PluginWithBackoff(10*time.Millisecond, 100*time.Millisecond))
+
+	require.ErrorIs(t, p.Touch(crashPort, 0, 0), touchctl.ErrPluginExited)
+	require.NoError(t, p.Close())
+
+	time.Sleep(200 * time.Millisecond)
+	require.ErrorIs(t, p.Touch(1, 0, 0), touchctl.ErrPluginExited)
+}
+
+func TestPlugin_CancelTouch(t *testing.T) {
+	cancels := filepath.Join(t.TempDir(), "cancels")
+	t.Setenv(fakePluginCancelsEnv, cancels)
+	p := newFakePlugin(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	startedAt := time.Now()
+	err := touchctl.TouchContext(ctx, p, 1, 0, time.Minute)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Less(t, time.Since(startedAt), 5*time.Second)
+
+	// the plugin was told to release the button and keeps serving
+	require.FileExists(t, cancels)
+	require.NoError(t, p.Touch(1, 0, 10*time.Millisecond))
+}
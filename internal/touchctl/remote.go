@@ -0,0 +1,120 @@
+package touchctl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/buglloc/yubictld/internal/xnet"
+	"github.com/buglloc/yubictld/pkg/yubictl"
+)
+
+const (
+	DefaultRemoteTimeout = 10 * time.Second
+	DefaultRemoteRetries = 3
+)
+
+var _ Toucher = (*Remote)(nil)
+var _ GestureToucher = (*Remote)(nil)
+
+// Remote forwards touches to the raw port touch endpoint of another yubictld.
+type Remote struct {
+	svc      *yubictl.SvcClient
+	toucher  string
+	timeout  time.Duration
+	location string
+}
+
+func NewRemote(upstream string, opts ...RemoteOption) (*Remote, error) {
+	if upstream == "" {
+		return nil, errors.New("no remote upstream configured")
+	}
+
+	r := &Remote{
+		timeout: DefaultRemoteTimeout,
+	}
+
+	var token string
+	retries := DefaultRemoteRetries
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case optRemoteToken:
+			token = v.token
+
+		case optRemoteTimeout:
+			if v.timeout > 0 {
+				r.timeout = v.timeout
+			}
+
+		case optRemoteToucher:
+			r.toucher = v.id
+
+		case optRemoteRetries:
+			if v.retries != 0 {
+				retries = max(v.retries, 0)
+			}
+
+		case optRemoteLocation:
+			r.location = v.location
+
+		default:
+			return nil, fmt.Errorf("invalid remote option: %T", opt)
+		}
+	}
+
+	if token == "" {
+		return nil, errors.New("remote toucher requires the remote admin token")
+	}
+
+	baseURL, transport := xnet.HTTPUpstream(upstream)
+	svcOpts := []yubictl.Option{
+		yubictl.WithAdminToken(token),
+		yubictl.WithRetryCount(retries),
+	}
+	if transport != nil {
+		svcOpts = append(svcOpts, yubictl.WithTransport(transport))
+	}
+
+	r.svc = yubictl.NewSvcClient(baseURL, svcOpts...)
+	return r, nil
+}
+
+func (r *Remote) Location() string {
+	return r.location
+}
+
+func (r *Remote) Touch(port int, delay time.Duration, duration time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), delay+duration+r.timeout)
+	defer cancel()
+
+	err := r.svc.TouchPort(ctx, r.toucher, port,
+		yubictl.TouchWithDelay(delay),
+		yubictl.TouchWithDuration(duration),
+	)
+	if err != nil {
+		return fmt.Errorf("remote touch: %w", err)
+	}
+
+	return nil
+}
+
+// TouchGesture sends the whole gesture in a single request, so the remote side keeps its timing.
+func (r *Remote) TouchGesture(ctx context.Context, port int, g Gesture) error {
+	ctx, cancel := context.WithTimeout(ctx, g.Duration()+r.timeout)
+	defer cancel()
+
+	steps := make([]yubictl.TouchStep, len(g))
+	for i, p := range g {
+		steps[i] = yubictl.TouchStep{
+			Delay:    p.Delay,
+			Duration: p.Duration,
+		}
+	}
+
+	if err := r.svc.TouchPort(ctx, r.toucher, port, yubictl.TouchWithSteps(steps...)); err != nil {
+		return fmt.Errorf("remote touch: %w", err)
+	}
+
+	return nil
+}
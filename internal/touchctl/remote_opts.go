@@ -0,0 +1,66 @@
+package touchctl
+
+import "time"
+
+type RemoteOption interface {
+	isRemoteOption()
+}
+
+type optRemoteToken struct {
+	RemoteOption
+	token string
+}
+
+func RemoteWithToken(token string) RemoteOption {
+	return optRemoteToken{
+		token: token,
+	}
+}
+
+type optRemoteTimeout struct {
+	RemoteOption
+	timeout time.Duration
+}
+
+// RemoteWithTimeout sets how long the remote may take to answer, on top of the touch delay and duration.
+func RemoteWithTimeout(timeout time.Duration) RemoteOption {
+	return optRemoteTimeout{
+		timeout: timeout,
+	}
+}
+
+type optRemoteRetries struct {
+	RemoteOption
+	retries int
+}
+
+// RemoteWithRetries sets how many times a failed touch request is retried, a negative count disables retries.
+func RemoteWithRetries(retries int) RemoteOption {
+	return optRemoteRetries{
+		retries: retries,
+	}
+}
+
+type optRemoteLocation struct {
+	RemoteOption
+	location string
+}
+
+// RemoteWithLocation sets the location of the local hub the remote toucher is wired to.
+func RemoteWithLocation(location string) RemoteOption {
+	return optRemoteLocation{
+		location: location,
+	}
+}
+
+type optRemoteToucher struct {
+	RemoteOption
+	id string
+}
+
+// RemoteWithToucher selects the toucher on the remote side, the remote default one is used otherwise.
+func RemoteWithToucher(id string) RemoteOption {
+	return optRemoteToucher{
+		id: id,
+	}
+}
@@ -0,0 +1,70 @@
+package touchctl_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/touchctl"
+	"github.com/buglloc/yubictld/pkg/yubictl"
+)
+
+type fakeAdmin struct {
+	mu       sync.Mutex
+	requests []yubictl.PortTouchReq
+}
+
+func (a *fakeAdmin) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/admin/touch" || r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var req yubictl.PortTouchReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	a.mu.Lock()
+	a.requests = append(a.requests, req)
+	a.mu.Unlock()
+}
+
+func (a *fakeAdmin) Requests() []yubictl.PortTouchReq {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return append([]yubictl.PortTouchReq(nil), a.requests...)
+}
+
+func TestRemote_GestureInOneRequest(t *testing.T) {
+	admin := &fakeAdmin{}
+	srv := httptest.NewServer(admin)
+	defer srv.Close()
+
+	remote, err := touchctl.NewRemote(srv.URL,
+		touchctl.RemoteWithToken("token"),
+		touchctl.RemoteWithToucher("rig"),
+	)
+	require.NoError(t, err)
+
+	gesture, err := touchctl.Preset(touchctl.GesturePresetDouble)
+	require.NoError(t, err)
+	require.NoError(t, touchctl.Perform(context.Background(), remote, 2, gesture))
+
+	requests := admin.Requests()
+	require.Len(t, requests, 1)
+	require.Equal(t, "rig", requests[0].Toucher)
+	require.Equal(t, 2, requests[0].Port)
+	require.Equal(t, []yubictl.TouchStep{
+		{Duration: 200 * time.Millisecond},
+		{Delay: 500 * time.Millisecond, Duration: 200 * time.Millisecond},
+	}, requests[0].Steps)
+}
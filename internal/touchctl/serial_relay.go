@@ -0,0 +1,195 @@
+package touchctl
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultSerialRelayBaud = 9600
+	// LCUS-type CH340 relay boards
+	DefaultSerialRelayOn  = "A0 CH 01 SUM"
+	DefaultSerialRelayOff = "A0 CH 00 SUM"
+)
+
+var _ Toucher = (*SerialRelay)(nil)
+
+type relayChannel struct {
+	on  []byte
+	off []byte
+}
+
+// SerialRelay touches Yubikeys with a multi-channel relay board driven over a serial port.
+type SerialRelay struct {
+	port     io.WriteCloser
+	channels map[int]relayChannel
+	location string
+	mu       sync.Mutex
+}
+
+func NewSerialRelay(opts ...SerialRelayOption) (*SerialRelay, error) {
+	var path, location string
+	var port io.WriteCloser
+	baud := DefaultSerialRelayBaud
+	on, off := DefaultSerialRelayOn, DefaultSerialRelayOff
+	var channels []optSerialRelayChannel
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case optSerialRelayDevice:
+			path = v.path
+
+		case optSerialRelayPort:
+			port = v.port
+
+		case optSerialRelayBaud:
+			if v.baud > 0 {
+				baud = v.baud
+			}
+
+		case optSerialRelayCommands:
+			if v.on != "" {
+				on = v.on
+			}
+
+			if v.off != "" {
+				off = v.off
+			}
+
+		case optSerialRelayChannel:
+			channels = append(channels, v)
+
+		case optSerialRelayLocation:
+			location = v.location
+
+		default:
+			return nil, fmt.Errorf("invalid serial relay option: %T", opt)
+		}
+	}
+
+	if path == "" && port == nil {
+		return nil, errors.New("no serial device configured")
+	}
+
+	if len(channels) == 0 {
+		return nil, errors.New("no relay channels configured")
+	}
+
+	r := &SerialRelay{
+		port:     port,
+		channels: make(map[int]relayChannel, len(channels)),
+		location: location,
+	}
+
+	for _, ch := range channels {
+		chOn, chOff := on, off
+		if ch.on != "" {
+			chOn = ch.on
+		}
+
+		if ch.off != "" {
+			chOff = ch.off
+		}
+
+		var rc relayChannel
+		var err error
+		if rc.on, err = ParseRelayCommand(chOn, ch.channel); err != nil {
+			return nil, fmt.Errorf("channel %d on command: %w", ch.channel, err)
+		}
+
+		if rc.off, err = ParseRelayCommand(chOff, ch.channel); err != nil {
+			return nil, fmt.Errorf("channel %d off command: %w", ch.channel, err)
+		}
+
+		r.channels[ch.port] = rc
+	}
+
+	if r.port != nil {
+		return r, nil
+	}
+
+	tty, err := openTTY(path, baud)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	r.port = tty
+
+	if r.location == "" {
+		if dev, err := filepath.EvalSymlinks(path); err == nil {
+			r.location = sysfsUSBLocation(filepath.Join("/sys/class/tty", filepath.Base(dev)))
+		}
+	}
+
+	return r, nil
+}
+
+func (r *SerialRelay) Location() string {
+	return r.location
+}
+
+func (r *SerialRelay) Touch(port int, delay time.Duration, duration time.Duration) error {
+	return r.TouchContext(context.Background(), port, delay, duration)
+}
+
+func (r *SerialRelay) TouchContext(ctx context.Context, port int, delay time.Duration, duration time.Duration) error {
+	ch, ok := r.channels[port]
+	if !ok {
+		return fmt.Errorf("no relay channel for port %d", port)
+	}
+
+	return press(ctx, delay, duration, func(on bool) error {
+		if on {
+			return r.write(ch.on)
+		}
+
+		return r.write(ch.off)
+	})
+}
+
+func (r *SerialRelay) Close() error {
+	return r.port.Close()
+}
+
+func (r *SerialRelay) write(cmd []byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.port.Write(cmd)
+	return err
+}
+
+// ParseRelayCommand renders the command template: space separated hex bytes,
+// where CH stands for the channel number and SUM for the low byte of the sum of the preceding bytes.
+func ParseRelayCommand(tmpl string, channel int) ([]byte, error) {
+	var out []byte
+	for _, tok := range strings.Fields(tmpl) {
+		switch strings.ToUpper(tok) {
+		case "CH":
+			out = append(out, byte(channel))
+		case "SUM":
+			var sum byte
+			for _, b := range out {
+				sum += b
+			}
+			out = append(out, sum)
+		default:
+			b, err := hex.DecodeString(tok)
+			if err != nil {
+				return nil, fmt.Errorf("invalid byte %q: %w", tok, err)
+			}
+			out = append(out, b...)
+		}
+	}
+
+	if len(out) == 0 {
+		return nil, errors.New("empty command")
+	}
+
+	return out, nil
+}
@@ -0,0 +1,62 @@
+package touchctl_test
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/buglloc/yubictld/internal/touchctl"
+)
+
+// openPTY returns the master side of a new pseudo terminal and the path of its slave.
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|unix.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pty support: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = master.Close()
+	})
+
+	n, err := unix.IoctlGetUint32(int(master.Fd()), unix.TIOCGPTN)
+	require.NoError(t, err)
+	require.NoError(t, unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, 0))
+
+	return master, fmt.Sprintf("/dev/pts/%d", n)
+}
+
+func TestSerialRelay_PTY(t *testing.T) {
+	master, slave := openPTY(t)
+
+	relay, err := touchctl.NewSerialRelay(
+		touchctl.SerialRelayWithDevice(slave),
+		touchctl.SerialRelayWithBaud(115200),
+		touchctl.SerialRelayWithChannel(1, 2, "", ""),
+		touchctl.SerialRelayWithChannel(2, 3, "FE 05 00 CH FF 00", "FE 05 00 CH 00 00"),
+	)
+	require.NoError(t, err)
+	defer func() { _ = relay.Close() }()
+
+	require.NoError(t, relay.Touch(1, 0, 10*time.Millisecond))
+	require.NoError(t, relay.Touch(2, 0, 10*time.Millisecond))
+
+	// the port is switched into the raw mode, so the commands arrive untouched
+	want := []byte{
+		0xA0, 0x02, 0x01, 0xA3,
+		0xA0, 0x02, 0x00, 0xA2,
+		0xFE, 0x05, 0x00, 0x03, 0xFF, 0x00,
+		0xFE, 0x05, 0x00, 0x03, 0x00, 0x00,
+	}
+	require.NoError(t, master.SetReadDeadline(time.Now().Add(5*time.Second)))
+	got := make([]byte, len(want))
+	_, err = io.ReadFull(master, got)
+	require.NoError(t, err)
+	require.Equal(t, want, got)
+}
@@ -0,0 +1,86 @@
+package touchctl
+
+import "io"
+
+type SerialRelayOption interface {
+	isSerialRelayOption()
+}
+
+type optSerialRelayDevice struct {
+	SerialRelayOption
+	path string
+}
+
+func SerialRelayWithDevice(path string) SerialRelayOption {
+	return optSerialRelayDevice{
+		path: path,
+	}
+}
+
+type optSerialRelayPort struct {
+	SerialRelayOption
+	port io.WriteCloser
+}
+
+// SerialRelayWithPort drives the relay board through the already opened port instead of the serial device.
+func SerialRelayWithPort(port io.WriteCloser) SerialRelayOption {
+	return optSerialRelayPort{
+		port: port,
+	}
+}
+
+type optSerialRelayBaud struct {
+	SerialRelayOption
+	baud int
+}
+
+func SerialRelayWithBaud(baud int) SerialRelayOption {
+	return optSerialRelayBaud{
+		baud: baud,
+	}
+}
+
+type optSerialRelayCommands struct {
+	SerialRelayOption
+	on  string
+	off string
+}
+
+// SerialRelayWithCommands sets the default on/off command templates, see ParseRelayCommand.
+func SerialRelayWithCommands(on, off string) SerialRelayOption {
+	return optSerialRelayCommands{
+		on:  on,
+		off: off,
+	}
+}
+
+type optSerialRelayChannel struct {
+	SerialRelayOption
+	port    int
+	channel int
+	on      string
+	off     string
+}
+
+// SerialRelayWithChannel maps the toucher port to the relay channel.
+// Non-empty on/off override the default command templates for this channel.
+func SerialRelayWithChannel(port, channel int, on, off string) SerialRelayOption {
+	return optSerialRelayChannel{
+		port:    port,
+		channel: channel,
+		on:      on,
+		off:     off,
+	}
+}
+
+type optSerialRelayLocation struct {
+	SerialRelayOption
+	location string
+}
+
+// SerialRelayWithLocation overrides the USB location derived from the tty parent device.
+func SerialRelayWithLocation(location string) SerialRelayOption {
+	return optSerialRelayLocation{
+		location: location,
+	}
+}
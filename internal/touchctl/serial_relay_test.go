@@ -0,0 +1,72 @@
+package touchctl_test
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/touchctl"
+)
+
+type fakePort struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	closed bool
+}
+
+func (p *fakePort) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.buf.Write(b)
+}
+
+func (p *fakePort) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.closed = true
+	return nil
+}
+
+func (p *fakePort) Bytes() []byte {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return bytes.Clone(p.buf.Bytes())
+}
+
+func TestParseRelayCommand(t *testing.T) {
+	cmd, err := touchctl.ParseRelayCommand(touchctl.DefaultSerialRelayOn, 4)
+	require.NoError(t, err)
+	require.Equal(t, []byte{0xA0, 0x04, 0x01, 0xA5}, cmd)
+
+	_, err = touchctl.ParseRelayCommand("A0 ZZ", 1)
+	require.Error(t, err)
+
+	_, err = touchctl.ParseRelayCommand("", 1)
+	require.Error(t, err)
+}
+
+func TestSerialRelay_CancelSwitchesOff(t *testing.T) {
+	port := &fakePort{}
+	relay, err := touchctl.NewSerialRelay(
+		touchctl.SerialRelayWithPort(port),
+		touchctl.SerialRelayWithChannel(1, 1, "", ""),
+	)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	err = relay.TouchContext(ctx, 1, 0, time.Minute)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Equal(t, []byte{0xA0, 0x01, 0x01, 0xA2, 0xA0, 0x01, 0x00, 0xA1}, port.Bytes())
+
+	require.NoError(t, relay.Close())
+	require.True(t, port.closed)
+}
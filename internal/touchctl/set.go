@@ -0,0 +1,79 @@
+package touchctl
+
+import (
+	"fmt"
+	"io"
+)
+
+// Set is a set of touchers addressed by their IDs, kept in the configuration order.
+type Set struct {
+	ids      []string
+	touchers map[string]Toucher
+}
+
+func NewSet() *Set {
+	return &Set{
+		touchers: make(map[string]Toucher),
+	}
+}
+
+func (s *Set) Add(id string, t Toucher) error {
+	if _, ok := s.touchers[id]; ok {
+		return fmt.Errorf("duplicate toucher: %s", id)
+	}
+
+	s.ids = append(s.ids, id)
+	s.touchers[id] = t
+	return nil
+}
+
+func (s *Set) Get(id string) (Toucher, bool) {
+	t, ok := s.touchers[id]
+	return t, ok
+}
+
+// Default returns the first toucher.
+func (s *Set) Default() (string, Toucher, bool) {
+	if len(s.ids) == 0 {
+		return "", nil, false
+	}
+
+	return s.ids[0], s.touchers[s.ids[0]], true
+}
+
+// Lookup returns the toucher by ID, or the default one when the ID is empty.
+func (s *Set) Lookup(id string) (string, Toucher, error) {
+	if id == "" {
+		id, t, ok := s.Default()
+		if !ok {
+			return "", nil, fmt.Errorf("no touchers configured")
+		}
+
+		return id, t, nil
+	}
+
+	t, ok := s.touchers[id]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown toucher: %s", id)
+	}
+
+	return id, t, nil
+}
+
+func (s *Set) IDs() []string {
+	return s.ids
+}
+
+func (s *Set) Len() int {
+	return len(s.ids)
+}
+
+func (s *Set) Close() error {
+	for _, t := range s.touchers {
+		if c, ok := t.(io.Closer); ok {
+			_ = c.Close()
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,37 @@
+package touchctl_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/simulator"
+	"github.com/buglloc/yubictld/internal/touchctl"
+)
+
+func TestSet_Lookup(t *testing.T) {
+	set := touchctl.NewSet()
+	_, _, err := set.Lookup("")
+	require.Error(t, err)
+
+	first := simulator.NewToucher("1-1")
+	second := simulator.NewToucher("2-1")
+	require.NoError(t, set.Add("first", first))
+	require.NoError(t, set.Add("second", second))
+	require.Error(t, set.Add("first", second))
+	require.Equal(t, []string{"first", "second"}, set.IDs())
+
+	// an empty ID picks the first configured toucher
+	id, toucher, err := set.Lookup("")
+	require.NoError(t, err)
+	require.Equal(t, "first", id)
+	require.Same(t, first, toucher)
+
+	id, toucher, err = set.Lookup("second")
+	require.NoError(t, err)
+	require.Equal(t, "second", id)
+	require.Same(t, second, toucher)
+
+	_, _, err = set.Lookup("third")
+	require.ErrorContains(t, err, "unknown toucher: third")
+}
@@ -1,7 +1,9 @@
 package touchctl
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"time"
 )
 
@@ -10,6 +12,46 @@ type Toucher interface {
 	Location() string
 }
 
+// ContextToucher is implemented by touchers able to stop a press in progress once ctx is done.
+// The button is released and ctx.Err() returned.
+type ContextToucher interface {
+	TouchContext(ctx context.Context, port int, delay time.Duration, duration time.Duration) error
+}
+
+// GestureToucher is implemented by touchers performing the whole gesture by themselves,
+// e.g. the remote one, which would otherwise make a round trip per press.
+type GestureToucher interface {
+	TouchGesture(ctx context.Context, port int, g Gesture) error
+}
+
+// TouchContext presses the button with the context aware toucher, or with a plain one otherwise.
+func TouchContext(ctx context.Context, t Toucher, port int, delay time.Duration, duration time.Duration) error {
+	if ct, ok := t.(ContextToucher); ok {
+		return ct.TouchContext(ctx, port, delay, duration)
+	}
+
+	return t.Touch(port, delay, duration)
+}
+
+// press waits for the delay and holds the button for the duration, switching it with set.
+// The button is released even if ctx is done meanwhile: one stuck pressed keeps the key touched.
+func press(ctx context.Context, delay time.Duration, duration time.Duration, set func(on bool) error) error {
+	if err := sleepContext(ctx, delay); err != nil {
+		return err
+	}
+
+	if err := set(true); err != nil {
+		return fmt.Errorf("press: %w", err)
+	}
+
+	holdErr := sleepContext(ctx, duration)
+	if err := set(false); err != nil {
+		return fmt.Errorf("release: %w", err)
+	}
+
+	return holdErr
+}
+
 type NopToucher struct{}
 
 func NewNopToucher() *NopToucher {
@@ -12,8 +12,13 @@ var _ encoding.TextMarshaler = (*ToucherKind)(nil)
 type ToucherKind string
 
 const (
-	ToucherKindNone   ToucherKind = ""
-	ToucherKindH4ptix ToucherKind = "h4ptix"
+	ToucherKindNone        ToucherKind = ""
+	ToucherKindH4ptix      ToucherKind = "h4ptix"
+	ToucherKindGPIO        ToucherKind = "gpio"
+	ToucherKindSerialRelay ToucherKind = "serial-relay"
+	ToucherKindHIDRelay    ToucherKind = "hidrelay"
+	ToucherKindPlugin      ToucherKind = "plugin"
+	ToucherKindRemote      ToucherKind = "remote"
 )
 
 func (k *ToucherKind) UnmarshalText(data []byte) error {
@@ -22,6 +27,16 @@ func (k *ToucherKind) UnmarshalText(data []byte) error {
 		*k = ToucherKindNone
 	case "h4ptix":
 		*k = ToucherKindH4ptix
+	case "gpio":
+		*k = ToucherKindGPIO
+	case "serial-relay":
+		*k = ToucherKindSerialRelay
+	case "hidrelay":
+		*k = ToucherKindHIDRelay
+	case "plugin":
+		*k = ToucherKindPlugin
+	case "remote":
+		*k = ToucherKindRemote
 	default:
 		return fmt.Errorf("invalid toucher kind: %s", string(data))
 	}
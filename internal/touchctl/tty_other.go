@@ -0,0 +1,13 @@
+//go:build !linux
+
+package touchctl
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+)
+
+func openTTY(_ string, _ int) (*os.File, error) {
+	return nil, fmt.Errorf("serial relay is not supported on %s", runtime.GOOS)
+}
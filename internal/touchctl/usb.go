@@ -0,0 +1,29 @@
+package touchctl
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// sysfsUSBLocation returns the location of the USB device the sysfs node belongs to, if any.
+func sysfsUSBLocation(node string) string {
+	dev, err := filepath.EvalSymlinks(node)
+	if err != nil {
+		return ""
+	}
+
+	for ; strings.HasPrefix(dev, "/sys/devices/"); dev = filepath.Dir(dev) {
+		if _, err := os.Stat(filepath.Join(dev, "busnum")); err == nil {
+			name := filepath.Base(dev)
+			if strings.HasPrefix(name, "usb") {
+				// root hub
+				return ""
+			}
+
+			return name
+		}
+	}
+
+	return ""
+}
@@ -0,0 +1,44 @@
+package xnet
+
+import (
+	"context"
+	"net"
+	"syscall"
+	"time"
+)
+
+const DefaultWatchInterval = 500 * time.Millisecond
+
+// WatchConn returns a context that is canceled as soon as the peer closes conn.
+// The returned cancel func must be called to stop watching.
+func WatchConn(parent context.Context, conn net.Conn, interval time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return ctx, cancel
+	}
+
+	rc, err := sc.SyscallConn()
+	if err != nil {
+		return ctx, cancel
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if isPeerClosed(rc) {
+					cancel()
+					return
+				}
+			}
+		}
+	}()
+
+	return ctx, cancel
+}
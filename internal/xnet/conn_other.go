@@ -0,0 +1,11 @@
+//go:build !linux && !darwin
+
+package xnet
+
+import (
+	"syscall"
+)
+
+func isPeerClosed(_ syscall.RawConn) bool {
+	return false
+}
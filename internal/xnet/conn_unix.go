@@ -0,0 +1,18 @@
+//go:build linux || darwin
+
+package xnet
+
+import (
+	"syscall"
+)
+
+func isPeerClosed(rc syscall.RawConn) bool {
+	closed := false
+	err := rc.Control(func(fd uintptr) {
+		var buf [1]byte
+		n, _, err := syscall.Recvfrom(int(fd), buf[:], syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		closed = n == 0 && err == nil
+	})
+
+	return err != nil || closed
+}
@@ -0,0 +1,27 @@
+package xnet
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// HTTPUpstream returns the base URL and the transport (nil for the default one)
+// to reach a yubictld at the address: listen address, unix socket path or URL.
+func HTTPUpstream(addr string) (string, http.RoundTripper) {
+	if strings.Contains(addr, "://") {
+		return addr, nil
+	}
+
+	if addr == "" || ParseNetwork(addr) != "unix" {
+		return "http://" + addr, nil
+	}
+
+	var d net.Dialer
+	return "http://unix", &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return d.DialContext(ctx, "unix", addr)
+		},
+	}
+}
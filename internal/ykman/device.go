@@ -0,0 +1,232 @@
+package ykman
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/buglloc/fidoctl"
+	"github.com/buglloc/usbhid"
+
+	"github.com/buglloc/yubictld/internal/ctaphid"
+)
+
+var _ encoding.TextUnmarshaler = (*BackendKind)(nil)
+var _ encoding.TextMarshaler = (*BackendKind)(nil)
+
+type BackendKind string
+
+const (
+	BackendKindHID  BackendKind = "hid"
+	BackendKindPCSC BackendKind = "pcsc"
+)
+
+func (k *BackendKind) UnmarshalText(data []byte) error {
+	switch strings.ToLower(string(data)) {
+	case "hid":
+		*k = BackendKindHID
+	case "pcsc":
+		*k = BackendKindPCSC
+	default:
+		return fmt.Errorf("invalid device backend kind: %s", string(data))
+	}
+	return nil
+}
+
+func (k BackendKind) MarshalText() ([]byte, error) {
+	return []byte(k), nil
+}
+
+// Device is a connected Yubikey as seen by a device backend.
+type Device interface {
+	Path() string
+	Location() string
+	String() string
+	Info() (DeviceInfo, error)
+	Reboot() error
+	// ResetFIDO performs authenticatorReset, the caller is responsible for touching the key.
+	ResetFIDO(ctx context.Context) error
+}
+
+// CCIDResetter is implemented by devices that can power cycle the Yubikey smart card
+// through its reader, which works even with the FIDO interface disabled.
+type CCIDResetter interface {
+	ResetCCID() error
+}
+
+// InPlaceRebooter is implemented by devices whose reboot keeps them enumerated under the same path,
+// like a smart card power cycled through its reader.
+type InPlaceRebooter interface {
+	RebootsInPlace() bool
+}
+
+// FallbackRebooter is implemented by devices that fall back to other backends when a reboot fails.
+// RebootVia returns the device that actually rebooted, as it defines how the Yubikey comes back.
+type FallbackRebooter interface {
+	RebootVia() (Device, error)
+}
+
+// ReaderDevice is implemented by devices reachable through a PC/SC reader.
+type ReaderDevice interface {
+	Reader() string
+}
+
+// Enumerator lists the Yubikeys currently connected to the host.
+type Enumerator interface {
+	Enumerate() ([]Device, error)
+}
+
+var _ Enumerator = (*HIDEnumerator)(nil)
+
+const (
+	fidoUsagePage = 0xF1D0
+	fidoUsage     = 0x01
+)
+
+// HIDEnumerator discovers Yubikeys, as well as FIDO authenticators of other vendors,
+// through their FIDO HID interface.
+type HIDEnumerator struct{}
+
+func NewHIDEnumerator() *HIDEnumerator {
+	return &HIDEnumerator{}
+}
+
+func (e *HIDEnumerator) Enumerate() ([]Device, error) {
+	yubikeys, err := fidoctl.Enumerate()
+	if err != nil {
+		return nil, err
+	}
+
+	authenticators, err := usbhid.Enumerate(
+		usbhid.WithDeviceFilterFunc(func(d *usbhid.Device) bool {
+			return d.UsagePage() == fidoUsagePage && d.Usage() == fidoUsage
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("enumerate FIDO devices: %w", err)
+	}
+
+	byPath := make(map[string]*usbhid.Device, len(authenticators))
+	for _, d := range authenticators {
+		byPath[d.Path()] = d
+	}
+
+	out := make([]Device, 0, len(authenticators))
+	for i := range yubikeys {
+		dev := &hidDevice{
+			Device: yubikeys[i],
+		}
+
+		if d, ok := byPath[dev.Path()]; ok {
+			dev.model = d.Product()
+			delete(byPath, dev.Path())
+		}
+
+		out = append(out, dev)
+	}
+
+	for _, d := range authenticators {
+		if _, ok := byPath[d.Path()]; !ok {
+			continue
+		}
+
+		out = append(out, &fidoDevice{
+			dev: d,
+		})
+	}
+
+	return out, nil
+}
+
+type hidDevice struct {
+	fidoctl.Device
+	model string
+}
+
+func (d *hidDevice) Info() (DeviceInfo, error) {
+	cfg, err := d.YubiConfig()
+	if err != nil {
+		return DeviceInfo{}, fmt.Errorf("get Yubikey config: %w", err)
+	}
+
+	info := infoFromConfig(cfg)
+	if d.model != "" {
+		info.Model = d.model
+	}
+
+	return info, nil
+}
+
+func (d *hidDevice) ResetFIDO(ctx context.Context) error {
+	return resetFIDO(ctx, d.Path())
+}
+
+// fidoDevice is a FIDO authenticator without the Yubico config interface.
+type fidoDevice struct {
+	dev *usbhid.Device
+}
+
+func (d *fidoDevice) Path() string {
+	return d.dev.Path()
+}
+
+func (d *fidoDevice) Location() string {
+	return d.dev.Location()
+}
+
+func (d *fidoDevice) String() string {
+	return d.dev.String()
+}
+
+// Info identifies the authenticator by its USB serial string or, lacking one, by its USB location.
+func (d *fidoDevice) Info() (DeviceInfo, error) {
+	var id string
+	switch {
+	case d.dev.SerialNumber() != "":
+		id = fmt.Sprintf("usb:%04x:%04x:%s", d.dev.VendorId(), d.dev.ProductId(), d.dev.SerialNumber())
+	case d.dev.Location() != "":
+		id = "location:" + d.dev.Location()
+	default:
+		return DeviceInfo{}, ErrUnidentifiable
+	}
+
+	vendor := d.dev.Manufacturer()
+	if vendor == "" {
+		vendor = fmt.Sprintf("%04x", d.dev.VendorId())
+	}
+
+	return DeviceInfo{
+		Serial:     pseudoSerial(id),
+		Interfaces: InterfaceFIDO,
+		Vendor:     vendor,
+		Model:      d.dev.Product(),
+	}, nil
+}
+
+func (d *fidoDevice) Reboot() error {
+	return fmt.Errorf("%s: reboot is supported by Yubikeys only", d)
+}
+
+func (d *fidoDevice) ResetFIDO(ctx context.Context) error {
+	return resetFIDO(ctx, d.Path())
+}
+
+// pseudoSerial derives a stable serial for authenticators that don't report one.
+// The top bit is always set, so it never clashes with the serial of a real Yubikey.
+func pseudoSerial(id string) uint32 {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(id))
+	return h.Sum32() | 1<<31
+}
+
+func resetFIDO(ctx context.Context, path string) error {
+	conn, err := ctaphid.Open(path)
+	if err != nil {
+		return fmt.Errorf("open CTAPHID: %w", err)
+	}
+	defer func() { _ = conn.Close() }()
+
+	return conn.Reset(ctx)
+}
@@ -0,0 +1,104 @@
+package ykman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/buglloc/yubictld/internal/ccid"
+)
+
+var _ Enumerator = (*CCIDEnumerator)(nil)
+
+// CCIDEnumerator discovers Yubikeys through their smart card interface,
+// so keys with FIDO disabled are visible as well.
+type CCIDEnumerator struct {
+	transport ccid.Transport
+}
+
+func NewCCIDEnumerator(transport ccid.Transport) *CCIDEnumerator {
+	return &CCIDEnumerator{
+		transport: transport,
+	}
+}
+
+func (e *CCIDEnumerator) Enumerate() ([]Device, error) {
+	readers, err := e.transport.Readers()
+	if err != nil {
+		return nil, fmt.Errorf("list readers: %w", err)
+	}
+
+	var out []Device
+	for _, reader := range readers {
+		if !ccid.IsYubikeyReader(reader) {
+			continue
+		}
+
+		out = append(out, &ccidDevice{
+			transport: e.transport,
+			reader:    reader,
+		})
+	}
+
+	return out, nil
+}
+
+type ccidDevice struct {
+	transport ccid.Transport
+	reader    string
+}
+
+func (d *ccidDevice) Path() string {
+	return "pcsc:" + d.reader
+}
+
+// Location is unknown: PC/SC doesn't expose the USB topology of readers.
+func (d *ccidDevice) Location() string {
+	return ""
+}
+
+func (d *ccidDevice) String() string {
+	return fmt.Sprintf("CCID reader %q", d.reader)
+}
+
+func (d *ccidDevice) Reader() string {
+	return d.reader
+}
+
+// Info connects in the shared mode, so it works while a lease holder has the card open.
+func (d *ccidDevice) Info() (DeviceInfo, error) {
+	card, err := d.transport.Connect(d.reader, ccid.ShareShared)
+	if err != nil {
+		return DeviceInfo{}, err
+	}
+	defer func() { _ = card.Close(false) }()
+
+	cfg, err := ccid.ReadConfig(card)
+	if err != nil {
+		return DeviceInfo{}, err
+	}
+
+	return infoFromConfig(cfg), nil
+}
+
+// Reboot power cycles the card through the reader, the CCID counterpart of the FIDO reboot.
+func (d *ccidDevice) Reboot() error {
+	card, err := d.transport.Connect(d.reader, ccid.ShareExclusive)
+	if err != nil {
+		return err
+	}
+
+	return card.Close(true)
+}
+
+func (d *ccidDevice) RebootsInPlace() bool {
+	return true
+}
+
+func (d *ccidDevice) ResetCCID() error {
+	return d.Reboot()
+}
+
+func (d *ccidDevice) ResetFIDO(_ context.Context) error {
+	return errors.New("FIDO reset is not supported over CCID")
+}
@@ -0,0 +1,113 @@
+package ykman_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/ccid"
+	"github.com/buglloc/yubictld/internal/simulator"
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+const ccidReader = "Yubico YubiKey CCID 00 00"
+
+// newCCIDYkMan runs a YkMan over the CCID readers of the transport, merged after the other backends.
+func newCCIDYkMan(t *testing.T, transport ccid.Transport, backends ...ykman.Enumerator) *ykman.YkMan {
+	t.Helper()
+
+	backends = append(backends, ykman.NewCCIDEnumerator(transport))
+	yk := ykman.NewYkMan(
+		ykman.WithEnumerator(ykman.NewMergedEnumerator(backends...)),
+		ykman.WithReapInterval(0),
+	)
+	t.Cleanup(func() {
+		_ = yk.Close()
+	})
+
+	require.NoError(t, yk.ReloadDevices())
+	return yk
+}
+
+func TestCCID_Discovery(t *testing.T) {
+	transport := ccid.NewScriptedTransport()
+	transport.AddReader(ccidReader, ccid.YubikeyScript(20000001, 5, 7, 1))
+	transport.AddReader("Some Other Reader 00 00", ccid.YubikeyScript(1, 5, 7, 1))
+
+	yk := newCCIDYkMan(t, transport)
+
+	devices := yk.Devices()
+	require.Len(t, devices, 1)
+	require.EqualValues(t, 20000001, devices[0].Serial())
+	require.Equal(t, "5.7.1", devices[0].Version().String())
+	require.Equal(t, ccidReader, devices[0].Reader())
+	require.True(t, devices[0].Interfaces().Has(ykman.InterfaceCCID))
+}
+
+func TestCCID_BusyCardKeepsLease(t *testing.T) {
+	script := ccid.YubikeyScript(20000002, 5, 4, 3)
+	transport := ccid.NewScriptedTransport()
+	transport.AddReader(ccidReader, script)
+
+	yk := newCCIDYkMan(t, transport)
+	acquired, err := yk.TryAcquire(ykman.AcquireReq{ClientIDs: []string{"client"}})
+	require.NoError(t, err)
+	require.Len(t, acquired, 1)
+
+	// a card opened in the shared mode by the holder is still readable
+	script.Hold(ccid.ShareShared)
+	require.NoError(t, yk.ReloadDevices())
+	require.False(t, acquired[0].IsMissing())
+
+	// the exclusively opened one is not, but it is still plugged in and keeps its lease
+	script.Hold(ccid.ShareExclusive)
+	require.NoError(t, yk.ReloadDevices())
+	require.False(t, acquired[0].IsMissing())
+
+	held, err := yk.ForClient("client")
+	require.NoError(t, err)
+	require.Equal(t, acquired[0], held)
+
+	// unlike the unplugged one
+	script.Release()
+	transport.RemoveReader(ccidReader)
+	require.NoError(t, yk.ReloadDevices())
+	require.True(t, acquired[0].IsMissing())
+}
+
+func TestCCID_RebootInPlace(t *testing.T) {
+	script := ccid.YubikeyScript(20000003, 5, 7, 1)
+	transport := ccid.NewScriptedTransport()
+	transport.AddReader(ccidReader, script)
+
+	yk := newCCIDYkMan(t, transport)
+	key := yk.Devices()[0]
+
+	// the reader never leaves the bus, so the card is accepted back under the same path
+	_, err := yk.Reboot(context.Background(), key, ykman.RebootStrategyKindFIDO)
+	require.NoError(t, err)
+	require.Equal(t, 1, script.Resets())
+	require.Equal(t, "pcsc:"+ccidReader, key.Path())
+}
+
+func TestCCID_RebootFallback(t *testing.T) {
+	sim := simulator.NewEnumerator(1, simulator.WithSerialBase(20000004))
+	dev := sim.Devices()[0]
+	script := ccid.YubikeyScript(dev.Serial(), 5, 7, 1)
+	transport := ccid.NewScriptedTransport()
+	transport.AddReader(ccidReader, script)
+
+	yk := newCCIDYkMan(t, transport, sim)
+	key := yk.Devices()[0]
+	prevPath := key.Path()
+
+	// the FIDO reboot fails, the card is power cycled instead and the key never leaves the bus
+	dev.Fail(simulator.OpReboot, errors.New("FIDO interface is disabled"))
+	_, err := yk.Reboot(context.Background(), key, ykman.RebootStrategyKindFIDO)
+	require.NoError(t, err)
+	require.Equal(t, 1, script.Resets())
+	require.Equal(t, prevPath, key.Path())
+	require.Zero(t, key.Failures())
+}
@@ -0,0 +1,166 @@
+package ykman
+
+import (
+	"context"
+	"errors"
+
+	"github.com/rs/zerolog/log"
+)
+
+var _ Enumerator = (*MergedEnumerator)(nil)
+var _ FallbackRebooter = (*mergedDevice)(nil)
+
+// MergedEnumerator combines several device backends, merging the devices
+// reported for the same serial into one. Backends go in the order of preference.
+type MergedEnumerator struct {
+	enumerators []Enumerator
+}
+
+func NewMergedEnumerator(enumerators ...Enumerator) *MergedEnumerator {
+	return &MergedEnumerator{
+		enumerators: enumerators,
+	}
+}
+
+func (e *MergedEnumerator) Enumerate() ([]Device, error) {
+	var (
+		out      []*mergedDevice
+		errs     []error
+		bySerial = make(map[uint32]*mergedDevice)
+	)
+
+	for _, enumerator := range e.enumerators {
+		devices, err := enumerator.Enumerate()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, dev := range devices {
+			info, err := dev.Info()
+			if err != nil {
+				// passed as is, so the pool keeps the known Yubikey with the same path, e.g. a card opened exclusively
+				log.Debug().
+					Err(err).
+					Str("device", dev.String()).
+					Msg("unable to identify device")
+				out = append(out, &mergedDevice{
+					devices: []Device{dev},
+				})
+				continue
+			}
+
+			serial := info.Serial
+			if merged, ok := bySerial[serial]; ok && serial != 0 {
+				merged.devices = append(merged.devices, dev)
+				continue
+			}
+
+			merged := &mergedDevice{
+				devices: []Device{dev},
+			}
+			bySerial[serial] = merged
+			out = append(out, merged)
+		}
+	}
+
+	if len(errs) == len(e.enumerators) && len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+
+	for _, err := range errs {
+		log.Warn().Err(err).Msg("device backend enumeration failed")
+	}
+
+	devices := make([]Device, len(out))
+	for i, dev := range out {
+		devices[i] = dev
+	}
+
+	return devices, nil
+}
+
+// mergedDevice is a single Yubikey reachable through several backends.
+type mergedDevice struct {
+	devices []Device
+}
+
+func (d *mergedDevice) primary() Device {
+	return d.devices[0]
+}
+
+func (d *mergedDevice) Path() string {
+	return d.primary().Path()
+}
+
+func (d *mergedDevice) Location() string {
+	for _, dev := range d.devices {
+		if loc := dev.Location(); loc != "" {
+			return loc
+		}
+	}
+
+	return ""
+}
+
+func (d *mergedDevice) String() string {
+	return d.primary().String()
+}
+
+// Reader returns the PC/SC reader name of the Yubikey if it has a CCID interface.
+func (d *mergedDevice) Reader() string {
+	for _, dev := range d.devices {
+		if r, ok := dev.(ReaderDevice); ok {
+			return r.Reader()
+		}
+	}
+
+	return ""
+}
+
+func (d *mergedDevice) Info() (DeviceInfo, error) {
+	var errs []error
+	for _, dev := range d.devices {
+		info, err := dev.Info()
+		if err == nil {
+			return info, nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return DeviceInfo{}, errors.Join(errs...)
+}
+
+func (d *mergedDevice) Reboot() error {
+	_, err := d.RebootVia()
+	return err
+}
+
+func (d *mergedDevice) RebootVia() (Device, error) {
+	var errs []error
+	for _, dev := range d.devices {
+		err := dev.Reboot()
+		if err == nil {
+			return dev, nil
+		}
+
+		errs = append(errs, err)
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+func (d *mergedDevice) ResetCCID() error {
+	for _, dev := range d.devices {
+		if c, ok := dev.(CCIDResetter); ok {
+			return c.ResetCCID()
+		}
+	}
+
+	return errors.New("no CCID interface")
+}
+
+func (d *mergedDevice) ResetFIDO(ctx context.Context) error {
+	return d.primary().ResetFIDO(ctx)
+}
@@ -4,7 +4,6 @@ import (
 	"encoding"
 	"fmt"
 	"runtime"
-	"strconv"
 	"strings"
 
 	"github.com/buglloc/yubictld/internal/touchctl"
@@ -39,67 +38,75 @@ func (k DiscoveryKind) MarshalText() ([]byte, error) {
 	return []byte(k), nil
 }
 
+// TouchPoint is where a Yubikey is touched: the toucher and its port.
+type TouchPoint struct {
+	ToucherID string
+	Toucher   touchctl.Toucher
+	Port      int
+}
+
+func (p TouchPoint) IsZero() bool {
+	return p.Toucher == nil || p.Port == 0
+}
+
 type Discovery interface {
-	Port(y *Yubikey) int
+	Locate(y *Yubikey) TouchPoint
 }
 
 type ManualDiscovery struct {
-	yubikeys map[uint32]int
+	yubikeys map[uint32]TouchPoint
 }
 
-func NewManualDiscovery(yubikeys map[uint32]int) (*ManualDiscovery, error) {
+func NewManualDiscovery(yubikeys map[uint32]TouchPoint) (*ManualDiscovery, error) {
 	return &ManualDiscovery{
 		yubikeys: yubikeys,
 	}, nil
 }
 
-func (d *ManualDiscovery) Port(y *Yubikey) int {
+func (d *ManualDiscovery) Locate(y *Yubikey) TouchPoint {
 	return d.yubikeys[y.Serial()]
 }
 
+// ToucherDiscovery assigns each Yubikey to the toucher sharing its hub.
 type ToucherDiscovery struct {
-	touch touchctl.Toucher
+	touchers *touchctl.Set
 }
 
-func NewToucherDiscovery(touch touchctl.Toucher) (*ToucherDiscovery, error) {
+func NewToucherDiscovery(touchers *touchctl.Set) (*ToucherDiscovery, error) {
 	if runtime.GOOS != "linux" {
 		return nil, fmt.Errorf("toucher discovery is no supported on %s so far", runtime.GOOS)
 	}
 
 	return &ToucherDiscovery{
-		touch: touch,
+		touchers: touchers,
 	}, nil
 }
 
-func (d *ToucherDiscovery) Port(y *Yubikey) int {
+func (d *ToucherDiscovery) Locate(y *Yubikey) TouchPoint {
 	yubiLocation := y.Location()
-	touchLocation := d.touch.Location()
-	if yubiLocation == "" || touchLocation == "" {
-		return 0
-	}
-
-	if d.hubLocation(yubiLocation) != d.hubLocation(touchLocation) {
-		return 0
+	if yubiLocation == "" {
+		return TouchPoint{}
 	}
 
-	return d.portLocation(yubiLocation)
-}
-
-func (d *ToucherDiscovery) hubLocation(loc string) string {
-	idx := strings.LastIndexByte(loc, '.')
-	if idx == -1 {
-		return ""
-	}
-
-	return loc[:idx]
-}
-
-func (d *ToucherDiscovery) portLocation(loc string) int {
-	idx := strings.LastIndexByte(loc, '.')
-	if idx == -1 {
-		return 0
+	yubiHub := hubLocation(yubiLocation)
+	for _, id := range d.touchers.IDs() {
+		touch, _ := d.touchers.Get(id)
+		touchLocation := touch.Location()
+		if touchLocation == "" {
+			continue
+		}
+
+		// the toucher is either plugged into the same hub or points to the hub itself
+		if hubLocation(touchLocation) != yubiHub && touchLocation != yubiHub {
+			continue
+		}
+
+		return TouchPoint{
+			ToucherID: id,
+			Toucher:   touch,
+			Port:      portLocation(yubiLocation),
+		}
 	}
 
-	port, _ := strconv.Atoi(loc[idx+1:])
-	return port
+	return TouchPoint{}
 }
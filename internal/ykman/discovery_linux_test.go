@@ -0,0 +1,61 @@
+package ykman_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/simulator"
+	"github.com/buglloc/yubictld/internal/touchctl"
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+func TestToucherDiscovery_RoutesByHub(t *testing.T) {
+	cases := []struct {
+		name     string
+		touchers map[string]string
+		want     string
+	}{
+		{
+			name:     "plugged into the hub",
+			touchers: map[string]string{"other": "2-1.4", "local": "1-1.4"},
+			want:     "local",
+		},
+		{
+			name:     "points to the hub",
+			touchers: map[string]string{"other": "2-1", "local": "1-1"},
+			want:     "local",
+		},
+		{
+			name:     "no toucher on the hub",
+			touchers: map[string]string{"other": "2-1", "nowhere": ""},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			set := touchctl.NewSet()
+			// the foreign hub goes first so the routing can't fall back to the default toucher
+			for _, id := range []string{"other", "local", "nowhere"} {
+				if loc, ok := tc.touchers[id]; ok {
+					require.NoError(t, set.Add(id, simulator.NewToucher(loc)))
+				}
+			}
+
+			discovery, err := ykman.NewToucherDiscovery(set)
+			require.NoError(t, err)
+
+			yk, sim := newSimYkMan(t, 2, ykman.WithDiscovery(discovery))
+			ports := sim.Ports()
+			for _, key := range yk.Devices() {
+				require.Equal(t, tc.want, key.ToucherID(), key.Location())
+				if tc.want == "" {
+					require.Zero(t, key.Port())
+					continue
+				}
+
+				require.Equal(t, ports[key.Serial()], key.Port())
+			}
+		})
+	}
+}
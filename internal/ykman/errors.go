@@ -4,3 +4,16 @@ import "errors"
 
 var ErrNoFreeYubikey = errors.New("no free Yubikey was found")
 var ErrNoAssociated = errors.New("associated Yubikey not found")
+var ErrNoMatchingYubikey = errors.New("no Yubikey matches the selector")
+var ErrNotYubikey = errors.New("device is not a Yubikey")
+var ErrUnmanaged = errors.New("Yubikey is not managed by discovery")
+var ErrUnknownYubikey = errors.New("unknown Yubikey")
+var ErrUnidentifiable = errors.New("device has neither a serial nor a stable location")
+var ErrRebootTimeout = errors.New("Yubikey did not come back after reboot")
+var ErrPowerUnsupported = errors.New("port power switching is not supported")
+var ErrResetUnsupported = errors.New("USB reset is not supported")
+var ErrPolicyUnsupported = errors.New("release policy is not supported by the authenticator")
+var ErrNoTouchPoint = errors.New("Yubikey is not wired to any toucher")
+var ErrUnknownTouch = errors.New("unknown touch")
+var ErrTouchExists = errors.New("touch ID is already taken")
+var ErrNotLeased = errors.New("Yubikey is not leased")
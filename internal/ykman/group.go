@@ -0,0 +1,88 @@
+package ykman
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Constraints restricts how the members of a lease group relate to each other.
+type Constraints struct {
+	SameHub          bool
+	DistinctVersions bool
+}
+
+// pick selects count keys out of candidates satisfying the constraints.
+// Keys are picked in the store order, so the result is stable.
+func (c Constraints) pick(candidates []*Yubikey, count int) []*Yubikey {
+	if count <= 0 || len(candidates) < count {
+		return nil
+	}
+
+	var buckets [][]*Yubikey
+	if c.SameHub {
+		hubs := make(map[string]int)
+		for _, yk := range candidates {
+			hub := hubLocation(yk.Location())
+			if hub == "" {
+				continue
+			}
+
+			idx, ok := hubs[hub]
+			if !ok {
+				idx = len(buckets)
+				hubs[hub] = idx
+				buckets = append(buckets, nil)
+			}
+			buckets[idx] = append(buckets[idx], yk)
+		}
+	} else {
+		buckets = [][]*Yubikey{candidates}
+	}
+
+	for _, bucket := range buckets {
+		if c.DistinctVersions {
+			bucket = distinctVersions(bucket)
+		}
+
+		if len(bucket) >= count {
+			return bucket[:count]
+		}
+	}
+
+	return nil
+}
+
+func distinctVersions(yks []*Yubikey) []*Yubikey {
+	seen := make(map[Version]struct{}, len(yks))
+	out := make([]*Yubikey, 0, len(yks))
+	for _, yk := range yks {
+		version := yk.Version()
+		if _, ok := seen[version]; ok {
+			continue
+		}
+
+		seen[version] = struct{}{}
+		out = append(out, yk)
+	}
+
+	return out
+}
+
+func hubLocation(loc string) string {
+	idx := strings.LastIndexByte(loc, '.')
+	if idx == -1 {
+		return ""
+	}
+
+	return loc[:idx]
+}
+
+func portLocation(loc string) int {
+	idx := strings.LastIndexByte(loc, '.')
+	if idx == -1 {
+		return 0
+	}
+
+	port, _ := strconv.Atoi(loc[idx+1:])
+	return port
+}
@@ -0,0 +1,135 @@
+package ykman
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Quarantine excludes the Yubikey from acquiring until it is released from quarantine.
+// The current holder, if any, keeps its lease.
+func (y *YkMan) Quarantine(serial uint32, reason string) (*Yubikey, error) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	yk, err := y.bySerialLocked(serial)
+	if err != nil {
+		return nil, err
+	}
+
+	y.quarantineLocked(yk, reason)
+	return yk, nil
+}
+
+func (y *YkMan) Unquarantine(serial uint32) (*Yubikey, error) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	yk, err := y.bySerialLocked(serial)
+	if err != nil {
+		return nil, err
+	}
+
+	yk.clearQuarantine()
+	log.Info().
+		Uint32("yk_serial", serial).
+		Msg("yubikey released from quarantine")
+
+	y.dispatchLocked()
+	return yk, nil
+}
+
+// ReportResult records the result of an operation on the Yubikey.
+// After too many consecutive failures the Yubikey is quarantined.
+func (y *YkMan) ReportResult(yk *Yubikey, opErr error) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	y.reportResultLocked(yk, opErr)
+}
+
+func (y *YkMan) reportResultLocked(yk *Yubikey, opErr error) {
+	failures := yk.recordResult(opErr)
+	if y.maxFailures <= 0 || failures < y.maxFailures || yk.IsQuarantined() {
+		return
+	}
+
+	y.quarantineLocked(yk, fmt.Sprintf("%d consecutive failures, last: %v", failures, opErr))
+}
+
+func (y *YkMan) healthLoop() {
+	ticker := time.NewTicker(y.healthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-y.closed:
+			return
+		case <-ticker.C:
+			y.checkHealth()
+		}
+	}
+}
+
+// checkHealth probes free Yubikeys only. A Yubikey is out of the pool while it is probed,
+// so the probe never runs against a lease holder.
+// Probe failures count towards the same consecutive failures limit as other operations.
+func (y *YkMan) checkHealth() {
+	for _, yk := range y.Devices() {
+		if !y.startProbe(yk) {
+			continue
+		}
+
+		err := yk.probe()
+		if err != nil {
+			err = fmt.Errorf("health probe: %w", err)
+		}
+
+		y.finishProbe(yk, err)
+	}
+}
+
+func (y *YkMan) startProbe(yk *Yubikey) bool {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if !yk.IsFree() || yk.IsMissing() || yk.IsQuarantined() || yk.IsCleaning() {
+		return false
+	}
+
+	yk.setProbing(true)
+	return true
+}
+
+func (y *YkMan) finishProbe(yk *Yubikey, probeErr error) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	yk.setProbing(false)
+	if !yk.IsFree() {
+		// the result says nothing about the holder's use of the key
+		return
+	}
+
+	y.reportResultLocked(yk, probeErr)
+	y.dispatchLocked()
+}
+
+func (y *YkMan) quarantineLocked(yk *Yubikey, reason string) {
+	yk.setQuarantine(reason)
+	log.Warn().
+		Uint32("yk_serial", yk.serial).
+		Str("reason", reason).
+		Msg("yubikey quarantined")
+}
+
+func (y *YkMan) bySerialLocked(serial uint32) (*Yubikey, error) {
+	for _, yk := range y.store {
+		if yk.serial == serial {
+			return yk, nil
+		}
+	}
+
+	return nil, fmt.Errorf("get Yubikey #%d: %w", serial, ErrUnknownYubikey)
+}
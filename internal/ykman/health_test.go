@@ -0,0 +1,136 @@
+package ykman_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/simulator"
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+func TestQuarantine_Manual(t *testing.T) {
+	yk, sim := newSimYkMan(t, 1)
+	serial := sim.Devices()[0].Serial()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	quarantined, err := yk.Quarantine(serial, "flaky")
+	require.NoError(t, err)
+	require.Equal(t, "flaky", quarantined.Quarantine().Reason)
+
+	waiting := acquireAsync(ctx, yk, ykman.AcquireReq{ClientIDs: []string{"a"}})
+	requireWaiting(t, waiting)
+
+	_, err = yk.Unquarantine(serial)
+	require.NoError(t, err)
+	require.Equal(t, quarantined, waitAcquired(t, waiting)[0])
+
+	_, err = yk.Quarantine(1, "unknown")
+	require.ErrorIs(t, err, ykman.ErrUnknownYubikey)
+}
+
+func TestQuarantine_HolderKeepsLease(t *testing.T) {
+	yk, _ := newSimYkMan(t, 1)
+
+	held := acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"a"}})
+	_, err := yk.Quarantine(held.Serial(), "manual")
+	require.NoError(t, err)
+
+	_, err = yk.Ping("a")
+	require.NoError(t, err)
+
+	_, err = yk.Release("a")
+	require.NoError(t, err)
+
+	_, err = yk.TryAcquire(ykman.AcquireReq{ClientIDs: []string{"b"}})
+	require.ErrorIs(t, err, ykman.ErrNoFreeYubikey)
+}
+
+func TestQuarantine_FailureThreshold(t *testing.T) {
+	yk, _ := newSimYkMan(t, 1, ykman.WithMaxFailures(2))
+	key := yk.Devices()[0]
+	opErr := errors.New("boom")
+
+	// a success in between resets the count
+	yk.ReportResult(key, opErr)
+	yk.ReportResult(key, nil)
+	yk.ReportResult(key, opErr)
+	require.False(t, key.IsQuarantined())
+	require.Equal(t, 1, key.Failures())
+
+	yk.ReportResult(key, opErr)
+	require.True(t, key.IsQuarantined())
+	require.Contains(t, key.Quarantine().Reason, "2 consecutive failures")
+
+	_, err := yk.Unquarantine(key.Serial())
+	require.NoError(t, err)
+	require.Zero(t, key.Failures())
+}
+
+func TestQuarantine_HealthProbes(t *testing.T) {
+	yk, sim := newSimYkMan(t, 2,
+		ykman.WithMaxFailures(2),
+		ykman.WithHealthInterval(20*time.Millisecond),
+	)
+
+	devices := sim.Devices()
+	held := acquireOne(t, yk, ykman.AcquireReq{
+		ClientIDs: []string{"a"},
+		Selector:  ykman.Selector{Serial: devices[1].Serial()},
+	})
+
+	for _, dev := range devices {
+		dev.Fail(simulator.OpInfo, errors.New("no answer"))
+	}
+
+	var free *ykman.Yubikey
+	for _, key := range yk.Devices() {
+		if key != held {
+			free = key
+		}
+	}
+
+	require.Eventually(t, free.IsQuarantined, 5*time.Second, 10*time.Millisecond)
+	require.Contains(t, free.Quarantine().Reason, "health probe")
+
+	// the leased key is never probed
+	require.False(t, held.IsQuarantined())
+	require.Zero(t, held.Failures())
+}
+
+func TestQuarantine_ProbedKeyIsNotLeased(t *testing.T) {
+	yk, sim := newSimYkMan(t, 1,
+		ykman.WithMaxFailures(1),
+		ykman.WithHealthInterval(10*time.Millisecond),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// the probe hangs on the key, as if it was opened by someone else
+	dev := sim.Devices()[0]
+	resume := dev.Stall(simulator.OpInfo)
+	defer resume()
+	time.Sleep(100 * time.Millisecond)
+
+	_, err := yk.TryAcquire(ykman.AcquireReq{ClientIDs: []string{"a"}})
+	require.ErrorIs(t, err, ykman.ErrNoFreeYubikey)
+
+	waiting := acquireAsync(ctx, yk, ykman.AcquireReq{ClientIDs: []string{"a"}})
+	dev.Fail(simulator.OpInfo, errors.New("busy"))
+	resume()
+
+	// the failed probe keeps the key away from the waiter
+	requireWaiting(t, waiting)
+	require.True(t, yk.Devices()[0].IsQuarantined())
+
+	dev.Fail(simulator.OpInfo, nil)
+	_, err = yk.Unquarantine(dev.Serial())
+	require.NoError(t, err)
+	require.Equal(t, yk.Devices()[0], waitAcquired(t, waiting)[0])
+}
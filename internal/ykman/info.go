@@ -0,0 +1,270 @@
+package ykman
+
+import (
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/buglloc/fidoctl"
+)
+
+var _ encoding.TextUnmarshaler = (*Version)(nil)
+var _ encoding.TextMarshaler = (*Version)(nil)
+
+type Version struct {
+	Major int
+	Minor int
+	Patch int
+}
+
+func ParseVersion(s string) (Version, error) {
+	var v Version
+	if s == "" {
+		return v, nil
+	}
+
+	parts := strings.SplitN(s, ".", 3)
+	dst := []*int{&v.Major, &v.Minor, &v.Patch}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return Version{}, fmt.Errorf("invalid version: %s", s)
+		}
+
+		*dst[i] = n
+	}
+
+	return v, nil
+}
+
+func (v Version) IsZero() bool {
+	return v == Version{}
+}
+
+func (v Version) Compare(other Version) int {
+	switch {
+	case v.Major != other.Major:
+		return v.Major - other.Major
+	case v.Minor != other.Minor:
+		return v.Minor - other.Minor
+	default:
+		return v.Patch - other.Patch
+	}
+}
+
+func (v *Version) UnmarshalText(data []byte) error {
+	parsed, err := ParseVersion(string(data))
+	if err != nil {
+		return err
+	}
+
+	*v = parsed
+	return nil
+}
+
+func (v Version) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+}
+
+var _ encoding.TextUnmarshaler = (*FormFactor)(nil)
+var _ encoding.TextMarshaler = (*FormFactor)(nil)
+
+type FormFactor uint8
+
+const (
+	FormFactorUnknown FormFactor = iota
+	FormFactorUSBAKeychain
+	FormFactorUSBANano
+	FormFactorUSBCKeychain
+	FormFactorUSBCNano
+	FormFactorUSBCLightning
+	FormFactorUSBABio
+	FormFactorUSBCBio
+)
+
+var formFactorNames = map[FormFactor]string{
+	FormFactorUnknown:       "unknown",
+	FormFactorUSBAKeychain:  "usb-a-keychain",
+	FormFactorUSBANano:      "usb-a-nano",
+	FormFactorUSBCKeychain:  "usb-c-keychain",
+	FormFactorUSBCNano:      "usb-c-nano",
+	FormFactorUSBCLightning: "usb-c-lightning",
+	FormFactorUSBABio:       "usb-a-bio",
+	FormFactorUSBCBio:       "usb-c-bio",
+}
+
+func (f *FormFactor) UnmarshalText(data []byte) error {
+	name := strings.ToLower(string(data))
+	if name == "" {
+		*f = FormFactorUnknown
+		return nil
+	}
+
+	for ff, ffName := range formFactorNames {
+		if ffName == name {
+			*f = ff
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid form factor: %s", string(data))
+}
+
+func (f FormFactor) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+func (f FormFactor) String() string {
+	if name, ok := formFactorNames[f]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("unknown(0x%02x)", uint8(f))
+}
+
+var _ encoding.TextUnmarshaler = (*Interface)(nil)
+var _ encoding.TextMarshaler = (*Interface)(nil)
+
+// Interface is a bitmask of the USB interfaces enabled on a Yubikey.
+type Interface uint8
+
+const (
+	InterfaceOTP Interface = 1 << iota
+	InterfaceFIDO
+	InterfaceCCID
+
+	InterfaceNone Interface = 0
+)
+
+// Yubico capability bits, as reported in the USB enabled config tag.
+const (
+	capOTP     = 0x0001
+	capU2F     = 0x0002
+	capOpenPGP = 0x0008
+	capPIV     = 0x0010
+	capOATH    = 0x0020
+	capHSMAuth = 0x0100
+	capFIDO2   = 0x0200
+)
+
+func ParseInterfaces(names []string) (Interface, error) {
+	var out Interface
+	for _, name := range names {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "":
+		case "otp":
+			out |= InterfaceOTP
+		case "fido":
+			out |= InterfaceFIDO
+		case "ccid":
+			out |= InterfaceCCID
+		default:
+			return InterfaceNone, fmt.Errorf("invalid interface: %s", name)
+		}
+	}
+
+	return out, nil
+}
+
+func (i Interface) Has(other Interface) bool {
+	return i&other == other
+}
+
+func (i Interface) Names() []string {
+	out := make([]string, 0, 3)
+	if i.Has(InterfaceOTP) {
+		out = append(out, "otp")
+	}
+	if i.Has(InterfaceFIDO) {
+		out = append(out, "fido")
+	}
+	if i.Has(InterfaceCCID) {
+		out = append(out, "ccid")
+	}
+	return out
+}
+
+func (i *Interface) UnmarshalText(data []byte) error {
+	parsed, err := ParseInterfaces(strings.Split(string(data), ","))
+	if err != nil {
+		return err
+	}
+
+	*i = parsed
+	return nil
+}
+
+func (i Interface) MarshalText() ([]byte, error) {
+	return []byte(i.String()), nil
+}
+
+func (i Interface) String() string {
+	return strings.Join(i.Names(), ",")
+}
+
+const (
+	VendorYubico = "Yubico"
+	ModelYubikey = "YubiKey"
+)
+
+// DeviceInfo identifies a device and describes its capabilities.
+type DeviceInfo struct {
+	Serial     uint32
+	Version    Version
+	FormFactor FormFactor
+	Interfaces Interface
+	Vendor     string
+	Model      string
+}
+
+func infoFromConfig(cfg *fidoctl.YubiConfig) DeviceInfo {
+	return DeviceInfo{
+		Serial:     cfg.Serial(),
+		Version:    Version(cfg.Version()),
+		FormFactor: parseFormFactor(cfg),
+		Interfaces: parseInterfaces(cfg),
+		Vendor:     VendorYubico,
+		Model:      ModelYubikey,
+	}
+}
+
+func parseFormFactor(cfg *fidoctl.YubiConfig) FormFactor {
+	raw := cfg.Get(fidoctl.ConfigTagFormFactor)
+	if len(raw) == 0 {
+		return FormFactorUnknown
+	}
+
+	// upper bits are flags (FIPS, SKY, etc.)
+	return FormFactor(raw[0] & 0x0f)
+}
+
+func parseInterfaces(cfg *fidoctl.YubiConfig) Interface {
+	raw := cfg.Get(fidoctl.ConfigTagUsbEnabled)
+	var caps uint16
+	switch len(raw) {
+	case 0:
+		return InterfaceNone
+	case 1:
+		caps = uint16(raw[0])
+	default:
+		caps = binary.BigEndian.Uint16(raw)
+	}
+
+	var out Interface
+	if caps&capOTP != 0 {
+		out |= InterfaceOTP
+	}
+	if caps&(capU2F|capFIDO2) != 0 {
+		out |= InterfaceFIDO
+	}
+	if caps&(capOpenPGP|capPIV|capOATH|capHSMAuth) != 0 {
+		out |= InterfaceCCID
+	}
+	return out
+}
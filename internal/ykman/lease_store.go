@@ -0,0 +1,300 @@
+package ykman
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// DefaultLeaseFlushDelay is how long FileLeaseStore may hold back lease renewals before writing them out.
+const DefaultLeaseFlushDelay = 5 * time.Second
+
+var _ encoding.TextUnmarshaler = (*LeaseStoreKind)(nil)
+var _ encoding.TextMarshaler = (*LeaseStoreKind)(nil)
+
+type LeaseStoreKind string
+
+const (
+	LeaseStoreKindMemory LeaseStoreKind = "memory"
+	LeaseStoreKindFile   LeaseStoreKind = "file"
+)
+
+func (k *LeaseStoreKind) UnmarshalText(data []byte) error {
+	switch strings.ToLower(string(data)) {
+	case "", "memory":
+		*k = LeaseStoreKindMemory
+	case "file":
+		*k = LeaseStoreKindFile
+	default:
+		return fmt.Errorf("invalid lease store kind: %s", string(data))
+	}
+	return nil
+}
+
+func (k LeaseStoreKind) MarshalText() ([]byte, error) {
+	return []byte(k), nil
+}
+
+type Lease struct {
+	Serial      uint32        `json:"serial"`
+	ClientID    string        `json:"client_id"`
+	GroupID     string        `json:"group_id,omitempty"`
+	LastAccess  time.Time     `json:"last_access"`
+	AcquiredAt  time.Time     `json:"acquired_at"`
+	TTL         time.Duration `json:"ttl,omitempty"`
+	MaxLifetime time.Duration `json:"max_lifetime,omitempty"`
+}
+
+// ExpiresAt returns the time the lease lapses unless it is renewed before.
+func (l Lease) ExpiresAt() time.Time {
+	expiresAt := l.LastAccess.Add(l.TTL)
+	if deadline := l.Deadline(); !deadline.IsZero() && deadline.Before(expiresAt) {
+		return deadline
+	}
+
+	return expiresAt
+}
+
+// Deadline returns the time the lease lapses regardless of renewals, zero if its lifetime is unlimited.
+func (l Lease) Deadline() time.Time {
+	if l.MaxLifetime <= 0 {
+		return time.Time{}
+	}
+
+	return l.AcquiredAt.Add(l.MaxLifetime)
+}
+
+// PingInterval returns the recommended keepalive interval, so a few pings may be lost before the lease lapses.
+func (l Lease) PingInterval() time.Duration {
+	interval := l.TTL / 3
+	switch {
+	case interval < MinPingInterval:
+		return MinPingInterval
+	case interval > MaxPingInterval:
+		return MaxPingInterval
+	default:
+		return interval
+	}
+}
+
+type LeaseStore interface {
+	Save(lease Lease) error
+	Delete(serial uint32) error
+	Load() ([]Lease, error)
+	Close() error
+}
+
+var _ LeaseStore = (*MemoryLeaseStore)(nil)
+
+type MemoryLeaseStore struct {
+	mu     sync.Mutex
+	leases map[uint32]Lease
+}
+
+func NewMemoryLeaseStore() *MemoryLeaseStore {
+	return &MemoryLeaseStore{
+		leases: make(map[uint32]Lease),
+	}
+}
+
+func (s *MemoryLeaseStore) Save(lease Lease) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.leases[lease.Serial] = lease
+	return nil
+}
+
+func (s *MemoryLeaseStore) Delete(serial uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.leases, serial)
+	return nil
+}
+
+func (s *MemoryLeaseStore) Load() ([]Lease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return sortedLeases(s.leases), nil
+}
+
+func (s *MemoryLeaseStore) Close() error {
+	return nil
+}
+
+var _ LeaseStore = (*FileLeaseStore)(nil)
+
+// FileLeaseStore keeps leases in a JSON file which is atomically rewritten on every change.
+// Renewals, which only move the lease last access, are batched and written out at most once per flush delay.
+type FileLeaseStore struct {
+	path       string
+	flushDelay time.Duration
+	mu         sync.Mutex
+	leases     map[uint32]Lease
+	flushTimer *time.Timer
+}
+
+func NewFileLeaseStore(path string) (*FileLeaseStore, error) {
+	if path == "" {
+		return nil, errors.New("empty lease store path")
+	}
+
+	if _, err := os.Stat(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("check lease store dir: %w", err)
+	}
+
+	s := &FileLeaseStore{
+		path:       path,
+		flushDelay: DefaultLeaseFlushDelay,
+		leases:     make(map[uint32]Lease),
+	}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return s, nil
+	case err != nil:
+		return nil, fmt.Errorf("read leases: %w", err)
+	}
+
+	var leases []Lease
+	if err := json.Unmarshal(data, &leases); err != nil {
+		return nil, fmt.Errorf("parse leases %q: %w", path, err)
+	}
+
+	for _, lease := range leases {
+		s.leases[lease.Serial] = lease
+	}
+
+	return s, nil
+}
+
+func (s *FileLeaseStore) Save(lease Lease) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	prev, ok := s.leases[lease.Serial]
+	s.leases[lease.Serial] = lease
+	if ok && isRenewal(prev, lease) {
+		if s.flushTimer == nil {
+			s.flushTimer = time.AfterFunc(s.flushDelay, s.delayedFlush)
+		}
+
+		return nil
+	}
+
+	return s.flushLocked()
+}
+
+func (s *FileLeaseStore) Delete(serial uint32) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.leases[serial]; !ok {
+		return nil
+	}
+
+	delete(s.leases, serial)
+	return s.flushLocked()
+}
+
+func (s *FileLeaseStore) Load() ([]Lease, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return sortedLeases(s.leases), nil
+}
+
+// Close writes out pending renewals.
+func (s *FileLeaseStore) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.flushTimer == nil {
+		return nil
+	}
+
+	return s.flushLocked()
+}
+
+func (s *FileLeaseStore) delayedFlush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.flushTimer == nil {
+		return
+	}
+
+	if err := s.flushLocked(); err != nil {
+		log.Error().Err(err).Str("path", s.path).Msg("flush leases failed")
+	}
+}
+
+func (s *FileLeaseStore) flushLocked() error {
+	if s.flushTimer != nil {
+		s.flushTimer.Stop()
+		s.flushTimer = nil
+	}
+
+	data, err := json.Marshal(sortedLeases(s.leases))
+	if err != nil {
+		return fmt.Errorf("marshal leases: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return fmt.Errorf("create temp file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write leases: %w", err)
+	}
+
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("sync leases: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("close leases: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return fmt.Errorf("rename leases: %w", err)
+	}
+
+	return nil
+}
+
+// isRenewal reports whether the lease differs from the previous one only by its last access.
+func isRenewal(prev, lease Lease) bool {
+	prev.LastAccess = lease.LastAccess
+	return prev == lease
+}
+
+func sortedLeases(leases map[uint32]Lease) []Lease {
+	out := make([]Lease, 0, len(leases))
+	for _, lease := range leases {
+		out = append(out, lease)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Serial < out[j].Serial
+	})
+	return out
+}
@@ -3,9 +3,19 @@ package ykman
 import "time"
 
 const (
-	DefaultLockTTL = time.Hour
+	DefaultLockTTL        = time.Hour
+	DefaultReapInterval   = 10 * time.Second
+	DefaultMissingGrace   = time.Minute
+	DefaultMaxFailures    = 3
+	MinPingInterval       = time.Second
+	MaxPingInterval       = time.Minute
+	DefaultCleanupTimeout = 2 * time.Minute
+	DefaultRebootTimeout  = 10 * time.Second
 )
 
+// MinLockTTL lets a lease survive a couple of lost pings at the shortest recommended interval.
+const MinLockTTL = 3 * MinPingInterval
+
 type Option func(*YkMan)
 
 func WithLockTTL(ttl time.Duration) Option {
@@ -14,8 +24,96 @@ func WithLockTTL(ttl time.Duration) Option {
 	}
 }
 
+func WithMaxLockTTL(ttl time.Duration) Option {
+	return func(y *YkMan) {
+		y.maxLockTTL = ttl
+	}
+}
+
+func WithMaxLifetime(lifetime time.Duration) Option {
+	return func(y *YkMan) {
+		y.maxLifetime = lifetime
+	}
+}
+
+func WithReapInterval(interval time.Duration) Option {
+	return func(y *YkMan) {
+		y.reapInterval = interval
+	}
+}
+
+func WithMissingGrace(grace time.Duration) Option {
+	return func(y *YkMan) {
+		y.missingGrace = grace
+	}
+}
+
+func WithMaxFailures(n int) Option {
+	return func(y *YkMan) {
+		y.maxFailures = n
+	}
+}
+
+func WithHealthInterval(interval time.Duration) Option {
+	return func(y *YkMan) {
+		y.healthInterval = interval
+	}
+}
+
+// WithReleasePolicies sets the policies applied in order to every released Yubikey.
+func WithReleasePolicies(policies ...ReleasePolicy) Option {
+	return func(y *YkMan) {
+		y.releasePolicies = policies
+	}
+}
+
+func WithCleanupTimeout(timeout time.Duration) Option {
+	return func(y *YkMan) {
+		y.cleanupTimeout = timeout
+	}
+}
+
+func WithEnumerator(enumerator Enumerator) Option {
+	return func(y *YkMan) {
+		y.enumerator = enumerator
+	}
+}
+
+// WithRebootTimeout sets how long a rebooted Yubikey may take to come back.
+func WithRebootTimeout(timeout time.Duration) Option {
+	return func(y *YkMan) {
+		y.rebootTimeout = timeout
+	}
+}
+
+// WithSysfsRoot overrides where sysfs is mounted, used to control Yubikeys on the USB level.
+func WithSysfsRoot(root string) Option {
+	return func(y *YkMan) {
+		y.sysfsRoot = root
+	}
+}
+
+// WithDevRoot overrides where devtmpfs is mounted, used to reach the usbfs nodes.
+func WithDevRoot(root string) Option {
+	return func(y *YkMan) {
+		y.devRoot = root
+	}
+}
+
+func WithPowerOffDelay(delay time.Duration) Option {
+	return func(y *YkMan) {
+		y.powerOffDelay = delay
+	}
+}
+
 func WithDiscovery(discovery Discovery) Option {
 	return func(y *YkMan) {
 		y.discovery = discovery
 	}
 }
+
+func WithLeaseStore(store LeaseStore) Option {
+	return func(y *YkMan) {
+		y.leases = store
+	}
+}
@@ -0,0 +1,128 @@
+package ykman
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var _ encoding.TextUnmarshaler = (*RebootStrategyKind)(nil)
+var _ encoding.TextMarshaler = (*RebootStrategyKind)(nil)
+
+type RebootStrategyKind string
+
+const (
+	RebootStrategyKindFIDO        RebootStrategyKind = "fido"
+	RebootStrategyKindUSBReset    RebootStrategyKind = "usb-reset"
+	RebootStrategyKindDeauthorize RebootStrategyKind = "deauthorize"
+)
+
+func (k *RebootStrategyKind) UnmarshalText(data []byte) error {
+	switch strings.ToLower(string(data)) {
+	case "", "fido":
+		*k = RebootStrategyKindFIDO
+	case "usb-reset":
+		*k = RebootStrategyKindUSBReset
+	case "deauthorize":
+		*k = RebootStrategyKindDeauthorize
+	default:
+		return fmt.Errorf("invalid reboot strategy: %s", string(data))
+	}
+	return nil
+}
+
+func (k RebootStrategyKind) MarshalText() ([]byte, error) {
+	return []byte(k), nil
+}
+
+// USBReset resets the Yubikey USB port and waits until it is enumerated again.
+func (y *Yubikey) USBReset(ctx context.Context, usb *USBControl, timeout time.Duration) (time.Duration, error) {
+	loc, err := y.usbLocation()
+	if err != nil {
+		return 0, err
+	}
+
+	return y.restart(ctx, func() (Device, error) {
+		return nil, usb.Reset(loc)
+	}, timeout)
+}
+
+// Deauthorize toggles the Yubikey USB authorization and waits until it is enumerated again.
+func (y *Yubikey) Deauthorize(ctx context.Context, usb *USBControl, timeout time.Duration) (time.Duration, error) {
+	loc, err := y.usbLocation()
+	if err != nil {
+		return 0, err
+	}
+
+	return y.restart(ctx, func() (Device, error) {
+		return nil, usb.Reauthorize(loc)
+	}, timeout)
+}
+
+// PowerCycle cuts the power of the Yubikey hub port for offDelay and waits until it is enumerated again.
+func (y *Yubikey) PowerCycle(ctx context.Context, usb *USBControl, offDelay, timeout time.Duration) (time.Duration, error) {
+	loc, err := y.usbLocation()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %w", ErrPowerUnsupported, err)
+	}
+
+	return y.restart(ctx, func() (Device, error) {
+		if err := usb.SetPortPower(loc, false); err != nil {
+			return nil, fmt.Errorf("power off: %w", err)
+		}
+
+		// the port must be powered back regardless of the request fate
+		select {
+		case <-ctx.Done():
+		case <-time.After(offDelay):
+		}
+
+		if err := usb.SetPortPower(loc, true); err != nil {
+			return nil, fmt.Errorf("power on: %w", err)
+		}
+
+		return nil, ctx.Err()
+	}, timeout)
+}
+
+func (y *Yubikey) usbLocation() (string, error) {
+	loc := y.Location()
+	if loc == "" {
+		return "", fmt.Errorf("%s has no USB location", y)
+	}
+
+	return loc, nil
+}
+
+// restart runs the reboot and waits until a device with the same serial is enumerated again,
+// taking over its new device handle. Returns how long the Yubikey was unavailable.
+// The reboot returns the device that went down, nil means the whole Yubikey leaves the bus.
+func (y *Yubikey) restart(ctx context.Context, reboot func() (Device, error), timeout time.Duration) (time.Duration, error) {
+	prevPath := y.device().Path()
+	startedAt := time.Now()
+	rebooted, err := reboot()
+	if err != nil {
+		return 0, fmt.Errorf("reboot: %w", err)
+	}
+
+	if r, ok := rebooted.(InPlaceRebooter); ok && r.RebootsInPlace() {
+		prevPath = ""
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := y.waitReenumerated(ctx, prevPath); err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return 0, fmt.Errorf("%s after %s: %w", y, timeout, ErrRebootTimeout)
+		}
+
+		return 0, fmt.Errorf("wait for re-enumeration: %w", err)
+	}
+
+	downtime := time.Since(startedAt)
+	return downtime, y.Ping()
+}
@@ -0,0 +1,48 @@
+package ykman_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/simulator"
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+func TestReboot_WaitsReenumeration(t *testing.T) {
+	const shutdownDelay = 500 * time.Millisecond
+
+	yk, sim := newSimYkMan(t, 1,
+		simulator.WithShutdownDelay(shutdownDelay),
+		simulator.WithRebootLatency(300*time.Millisecond),
+		ykman.WithRebootTimeout(5*time.Second),
+	)
+
+	key := yk.Devices()[0]
+	oldPath := key.Path()
+
+	// the key keeps answering under the old path for a while, which is not the rebooted one yet
+	downtime, err := yk.Reboot(context.Background(), key, ykman.RebootStrategyKindFIDO)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, downtime, shutdownDelay)
+	require.Equal(t, 1, sim.Devices()[0].Reboots())
+
+	require.NotEqual(t, oldPath, key.Path())
+	require.Equal(t, sim.Devices()[0].Path(), key.Path())
+	require.False(t, key.IsMissing())
+	require.Zero(t, key.Failures())
+}
+
+func TestReboot_Timeout(t *testing.T) {
+	yk, _ := newSimYkMan(t, 1,
+		simulator.WithRebootLatency(time.Minute),
+		ykman.WithRebootTimeout(500*time.Millisecond),
+	)
+
+	key := yk.Devices()[0]
+	_, err := yk.Reboot(context.Background(), key, ykman.RebootStrategyKindFIDO)
+	require.ErrorIs(t, err, ykman.ErrRebootTimeout)
+	require.Equal(t, 1, key.Failures())
+}
@@ -0,0 +1,264 @@
+package ykman
+
+import (
+	"context"
+	"encoding"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/buglloc/yubictld/internal/touchctl"
+)
+
+var _ encoding.TextUnmarshaler = (*ReleasePolicyKind)(nil)
+var _ encoding.TextMarshaler = (*ReleasePolicyKind)(nil)
+
+type ReleasePolicyKind string
+
+const (
+	ReleasePolicyKindReboot    ReleasePolicyKind = "reboot"
+	ReleasePolicyKindFIDOReset ReleasePolicyKind = "fido-reset"
+	ReleasePolicyKindPIVReset  ReleasePolicyKind = "piv-reset"
+	ReleasePolicyKindOATHReset ReleasePolicyKind = "oath-reset"
+	ReleasePolicyKindCCIDReset ReleasePolicyKind = "ccid-reset"
+)
+
+const (
+	DefaultRebootWait = 10 * time.Second
+	DefaultYkmanPath  = "ykman"
+)
+
+func (k *ReleasePolicyKind) UnmarshalText(data []byte) error {
+	switch strings.ToLower(string(data)) {
+	case "reboot":
+		*k = ReleasePolicyKindReboot
+	case "fido-reset":
+		*k = ReleasePolicyKindFIDOReset
+	case "piv-reset":
+		*k = ReleasePolicyKindPIVReset
+	case "oath-reset":
+		*k = ReleasePolicyKindOATHReset
+	case "ccid-reset":
+		*k = ReleasePolicyKindCCIDReset
+	default:
+		return fmt.Errorf("invalid release policy kind: %s", string(data))
+	}
+	return nil
+}
+
+func (k ReleasePolicyKind) MarshalText() ([]byte, error) {
+	return []byte(k), nil
+}
+
+// ReleasePolicy brings a released Yubikey back to a clean state before the next lease.
+// A policy that wipes data fails for an authenticator it can't be applied to, so the key is quarantined
+// instead of being handed out with the previous holder credentials.
+type ReleasePolicy interface {
+	Apply(ctx context.Context, yk *Yubikey) error
+	Kind() ReleasePolicyKind
+}
+
+var _ ReleasePolicy = (*RebootPolicy)(nil)
+
+type RebootPolicy struct {
+	wait time.Duration
+}
+
+func NewRebootPolicy(wait time.Duration) *RebootPolicy {
+	if wait <= 0 {
+		wait = DefaultRebootWait
+	}
+
+	return &RebootPolicy{
+		wait: wait,
+	}
+}
+
+func (p *RebootPolicy) Apply(ctx context.Context, yk *Yubikey) error {
+	if yk.Vendor() != VendorYubico {
+		// nothing is wiped by a reboot, so the key is still fine to hand out
+		warnSkipped(yk, p.Kind(), "only Yubikeys can be rebooted")
+		return nil
+	}
+
+	return rebootAndWait(ctx, yk, p.wait)
+}
+
+func (p *RebootPolicy) Kind() ReleasePolicyKind {
+	return ReleasePolicyKindReboot
+}
+
+var _ ReleasePolicy = (*CCIDResetPolicy)(nil)
+
+// CCIDResetPolicy power cycles the Yubikey smart card through its reader.
+type CCIDResetPolicy struct {
+	wait time.Duration
+}
+
+func NewCCIDResetPolicy(wait time.Duration) *CCIDResetPolicy {
+	if wait <= 0 {
+		wait = DefaultRebootWait
+	}
+
+	return &CCIDResetPolicy{
+		wait: wait,
+	}
+}
+
+func (p *CCIDResetPolicy) Apply(ctx context.Context, yk *Yubikey) error {
+	resetter, ok := yk.device().(CCIDResetter)
+	if !ok {
+		if yk.Vendor() != VendorYubico {
+			warnSkipped(yk, p.Kind(), "authenticator has no smart card")
+			return nil
+		}
+
+		return fmt.Errorf("%s has no CCID interface", yk)
+	}
+
+	if err := resetter.ResetCCID(); err != nil {
+		return fmt.Errorf("reset CCID: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.wait)
+	defer cancel()
+
+	// the card is reset in place, the reader stays on the bus
+	if err := yk.waitReenumerated(ctx, ""); err != nil {
+		return fmt.Errorf("wait for re-enumeration: %w", err)
+	}
+
+	return nil
+}
+
+func (p *CCIDResetPolicy) Kind() ReleasePolicyKind {
+	return ReleasePolicyKindCCIDReset
+}
+
+var _ ReleasePolicy = (*FIDOResetPolicy)(nil)
+
+// FIDOResetPolicy wipes FIDO credentials and PIN with authenticatorReset.
+// The authenticator only accepts the reset within seconds after power up and requires a touch,
+// so the key is rebooted first and touched by its toucher.
+type FIDOResetPolicy struct {
+	wait time.Duration
+}
+
+func NewFIDOResetPolicy(wait time.Duration) *FIDOResetPolicy {
+	if wait <= 0 {
+		wait = DefaultRebootWait
+	}
+
+	return &FIDOResetPolicy{
+		wait: wait,
+	}
+}
+
+func (p *FIDOResetPolicy) Apply(ctx context.Context, yk *Yubikey) error {
+	if yk.Port() == 0 {
+		return fmt.Errorf("%s: %w", yk, ErrNoTouchPoint)
+	}
+
+	// other authenticators can't be rebooted into the reset window, the reset fails unless they accept it anyway
+	if yk.Vendor() == VendorYubico {
+		if err := rebootAndWait(ctx, yk, p.wait); err != nil {
+			return err
+		}
+	}
+
+	var wg sync.WaitGroup
+	var touchErr error
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		touchErr = yk.Touch(ctx, touchctl.Gesture{
+			{Delay: 500 * time.Millisecond, Duration: 500 * time.Millisecond},
+		})
+	}()
+
+	resetErr := yk.device().ResetFIDO(ctx)
+	wg.Wait()
+	if resetErr != nil {
+		return fmt.Errorf("authenticator reset: %w", errors.Join(resetErr, touchErr))
+	}
+
+	return nil
+}
+
+func (p *FIDOResetPolicy) Kind() ReleasePolicyKind {
+	return ReleasePolicyKindFIDOReset
+}
+
+var _ ReleasePolicy = (*YkmanPolicy)(nil)
+
+// YkmanPolicy resets a CCID application with the Yubico ykman CLI.
+type YkmanPolicy struct {
+	kind ReleasePolicyKind
+	path string
+	args []string
+}
+
+func NewPIVResetPolicy(ykmanPath string) *YkmanPolicy {
+	return newYkmanPolicy(ReleasePolicyKindPIVReset, ykmanPath, "piv", "reset", "--force")
+}
+
+func NewOATHResetPolicy(ykmanPath string) *YkmanPolicy {
+	return newYkmanPolicy(ReleasePolicyKindOATHReset, ykmanPath, "oath", "reset", "--force")
+}
+
+func newYkmanPolicy(kind ReleasePolicyKind, path string, args ...string) *YkmanPolicy {
+	if path == "" {
+		path = DefaultYkmanPath
+	}
+
+	return &YkmanPolicy{
+		kind: kind,
+		path: path,
+		args: args,
+	}
+}
+
+func (p *YkmanPolicy) Apply(ctx context.Context, yk *Yubikey) error {
+	if yk.Vendor() != VendorYubico {
+		if !yk.Interfaces().Has(InterfaceCCID) {
+			warnSkipped(yk, p.Kind(), "authenticator has no smart card")
+			return nil
+		}
+
+		return fmt.Errorf("%s: %w", yk.Vendor(), ErrPolicyUnsupported)
+	}
+
+	args := append([]string{"--device", strconv.FormatUint(uint64(yk.Serial()), 10)}, p.args...)
+	out, err := exec.CommandContext(ctx, p.path, args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ykman %s: %w: %s", strings.Join(p.args, " "), err, strings.TrimSpace(string(out)))
+	}
+
+	return nil
+}
+
+func (p *YkmanPolicy) Kind() ReleasePolicyKind {
+	return p.kind
+}
+
+// warnSkipped logs the policy which has nothing to do for the authenticator.
+func warnSkipped(yk *Yubikey, kind ReleasePolicyKind, reason string) {
+	log.Warn().
+		Uint32("yk_serial", yk.Serial()).
+		Str("vendor", yk.Vendor()).
+		Str("policy", string(kind)).
+		Str("reason", reason).
+		Msg("release policy skipped")
+}
+
+// rebootAndWait reboots the Yubikey and waits until it is enumerated again.
+func rebootAndWait(ctx context.Context, yk *Yubikey, wait time.Duration) error {
+	_, err := yk.Reboot(ctx, wait)
+	return err
+}
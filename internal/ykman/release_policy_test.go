@@ -0,0 +1,85 @@
+package ykman_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/simulator"
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+func releaseAndClean(t *testing.T, yk *ykman.YkMan, clientID string) *ykman.Yubikey {
+	t.Helper()
+
+	acquired, err := yk.TryAcquire(ykman.AcquireReq{ClientIDs: []string{clientID}})
+	require.NoError(t, err)
+	require.Len(t, acquired, 1)
+
+	_, err = yk.Release(clientID)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return !acquired[0].IsCleaning()
+	}, 5*time.Second, 10*time.Millisecond)
+
+	return acquired[0]
+}
+
+func TestReleasePolicy_Reboot(t *testing.T) {
+	yk, sim := newSimYkMan(t, 1, ykman.WithReleasePolicies(ykman.NewRebootPolicy(time.Second)))
+
+	released := releaseAndClean(t, yk, "client")
+	require.False(t, released.IsQuarantined())
+	require.Equal(t, 1, sim.Devices()[0].Reboots())
+}
+
+func newForeignYkMan(t *testing.T, policies ...ykman.ReleasePolicy) (*ykman.YkMan, *simulator.Enumerator) {
+	t.Helper()
+
+	return newSimYkMan(t, 1,
+		simulator.WithVendor("Feitian"),
+		withToucher{simulator.NewToucher(simulator.DefaultHubLocation)},
+		ykman.WithReleasePolicies(policies...),
+	)
+}
+
+func TestReleasePolicy_ForeignVendor(t *testing.T) {
+	yk, sim := newForeignYkMan(t,
+		ykman.NewRebootPolicy(time.Second),
+		ykman.NewFIDOResetPolicy(time.Second),
+	)
+
+	// the authenticator can't be rebooted, but is still reset
+	released := releaseAndClean(t, yk, "client")
+	require.False(t, released.IsQuarantined())
+	require.Zero(t, sim.Devices()[0].Reboots())
+	require.Equal(t, 1, sim.Devices()[0].FIDOResets())
+
+	acquired, err := yk.TryAcquire(ykman.AcquireReq{ClientIDs: []string{"next"}})
+	require.NoError(t, err)
+	require.Equal(t, released, acquired[0])
+}
+
+func TestReleasePolicy_ForeignVendorNotWiped(t *testing.T) {
+	t.Run("fido-reset", func(t *testing.T) {
+		yk, sim := newForeignYkMan(t, ykman.NewFIDOResetPolicy(time.Second))
+		sim.Devices()[0].Fail(simulator.OpResetFIDO, errors.New("not in the reset window"))
+
+		released := releaseAndClean(t, yk, "client")
+		require.True(t, released.IsQuarantined())
+		require.Contains(t, released.Quarantine().Reason, "not in the reset window")
+	})
+
+	t.Run("piv-reset", func(t *testing.T) {
+		yk, _ := newForeignYkMan(t, ykman.NewPIVResetPolicy(""))
+
+		released := releaseAndClean(t, yk, "client")
+		require.True(t, released.IsQuarantined())
+		require.Contains(t, released.Quarantine().Reason, ykman.ErrPolicyUnsupported.Error())
+
+		_, err := yk.TryAcquire(ykman.AcquireReq{ClientIDs: []string{"next"}})
+		require.ErrorIs(t, err, ykman.ErrNoFreeYubikey)
+	})
+}
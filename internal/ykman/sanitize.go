@@ -0,0 +1,52 @@
+package ykman
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// sanitizeLocked applies the release policies to the just released Yubikey in the background.
+// Until they are done the Yubikey is kept in the cleaning state and can't be acquired.
+func (y *YkMan) sanitizeLocked(yk *Yubikey) {
+	if len(y.releasePolicies) == 0 || yk.IsMissing() {
+		return
+	}
+
+	yk.setCleaning(true)
+	go y.sanitize(yk)
+}
+
+func (y *YkMan) sanitize(yk *Yubikey) {
+	ctx, cancel := context.WithTimeout(context.Background(), y.cleanupTimeout)
+	defer cancel()
+
+	startedAt := time.Now()
+	var err error
+	for _, policy := range y.releasePolicies {
+		if err = policy.Apply(ctx, yk); err != nil {
+			err = fmt.Errorf("%s: %w", policy.Kind(), err)
+			break
+		}
+	}
+
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	yk.setCleaning(false)
+	yk.recordResult(err)
+	if err != nil {
+		// a Yubikey which may still hold the previous holder credentials is never handed out
+		y.quarantineLocked(yk, fmt.Sprintf("cleanup failed: %v", err))
+		return
+	}
+
+	log.Info().
+		Uint32("yk_serial", yk.serial).
+		Dur("elapsed", time.Since(startedAt)).
+		Msg("yubikey cleaned up")
+
+	y.dispatchLocked()
+}
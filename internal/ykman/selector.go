@@ -0,0 +1,45 @@
+package ykman
+
+// Selector restricts which Yubikeys may be handed out on acquire.
+// Zero-valued fields match any key.
+type Selector struct {
+	Serial     uint32
+	MinVersion Version
+	MaxVersion Version
+	FormFactor FormFactor
+	Interfaces Interface
+	Location   string
+	Touchable  bool
+}
+
+func (s Selector) Match(y *Yubikey) bool {
+	if s.Serial != 0 && y.serial != s.Serial {
+		return false
+	}
+
+	if !s.MinVersion.IsZero() && y.Version().Compare(s.MinVersion) < 0 {
+		return false
+	}
+
+	if !s.MaxVersion.IsZero() && y.Version().Compare(s.MaxVersion) > 0 {
+		return false
+	}
+
+	if s.FormFactor != FormFactorUnknown && y.FormFactor() != s.FormFactor {
+		return false
+	}
+
+	if !y.Interfaces().Has(s.Interfaces) {
+		return false
+	}
+
+	if s.Location != "" && y.Location() != s.Location {
+		return false
+	}
+
+	if s.Touchable && y.Port() == 0 {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,224 @@
+package ykman
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/buglloc/yubictld/internal/touchctl"
+)
+
+// touchRetention is how long the status of a finished touch is kept around.
+const touchRetention = 10 * time.Minute
+
+type TouchState string
+
+const (
+	TouchStateScheduled TouchState = "scheduled"
+	TouchStateActuating TouchState = "actuating"
+	TouchStateDone      TouchState = "done"
+	TouchStateFailed    TouchState = "failed"
+	TouchStateCanceled  TouchState = "canceled"
+)
+
+func (s TouchState) IsFinal() bool {
+	return s == TouchStateDone || s == TouchStateFailed || s == TouchStateCanceled
+}
+
+type TouchStatus struct {
+	ID          string
+	ClientID    string
+	Serial      uint32
+	State       TouchState
+	Err         error
+	ScheduledAt time.Time
+	StartedAt   time.Time
+	FinishedAt  time.Time
+}
+
+type touchTask struct {
+	yk      *Yubikey
+	gesture touchctl.Gesture
+	cancel  context.CancelFunc
+	done    chan struct{}
+	mu      sync.Mutex
+	status  TouchStatus
+}
+
+func (t *touchTask) Status() TouchStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.status
+}
+
+func (t *touchTask) run(ctx context.Context) error {
+	defer close(t.done)
+	defer t.cancel()
+
+	err := t.perform(ctx)
+
+	t.mu.Lock()
+	t.status.FinishedAt = time.Now()
+	switch {
+	case err == nil:
+		t.status.State = TouchStateDone
+	case errors.Is(err, context.Canceled):
+		t.status.State = TouchStateCanceled
+	default:
+		t.status.State = TouchStateFailed
+		t.status.Err = err
+	}
+	status := t.status
+	t.mu.Unlock()
+
+	if status.State == TouchStateFailed {
+		log.Error().
+			Err(err).
+			Str("touch_id", status.ID).
+			Str("client_id", status.ClientID).
+			Uint32("yk_serial", status.Serial).
+			Msg("touch failed")
+	}
+
+	t.yk.removeTouch(t)
+	return err
+}
+
+func (t *touchTask) perform(ctx context.Context) error {
+	// the first press delay is waited here, so the touch is "actuating" only once the toucher is engaged
+	gesture := append(touchctl.Gesture(nil), t.gesture...)
+	timer := time.NewTimer(gesture[0].Delay)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+	}
+
+	t.mu.Lock()
+	t.status.State = TouchStateActuating
+	t.status.StartedAt = time.Now()
+	t.mu.Unlock()
+
+	gesture[0].Delay = 0
+	return t.yk.Touch(ctx, gesture)
+}
+
+// ScheduleTouch starts performing the gesture on the leased Yubikey in background.
+// The touch is canceled once the lease is released.
+// Scheduling an already known touch of the same lease returns its status instead, so a retried request touches once.
+func (y *YkMan) ScheduleTouch(touchID string, yk *Yubikey, g touchctl.Gesture) (TouchStatus, error) {
+	if len(g) == 0 {
+		return TouchStatus{}, errors.New("empty gesture")
+	}
+
+	y.touchMu.Lock()
+	defer y.touchMu.Unlock()
+
+	if prev, ok := y.touches[touchID]; ok {
+		status := prev.Status()
+		if prev.yk != yk || status.ClientID != yk.Lease().ClientID {
+			return TouchStatus{}, fmt.Errorf("touch %q: %w", touchID, ErrTouchExists)
+		}
+
+		return status, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	task := &touchTask{
+		yk:      yk,
+		gesture: g,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		status: TouchStatus{
+			ID:          touchID,
+			Serial:      yk.Serial(),
+			State:       TouchStateScheduled,
+			ScheduledAt: time.Now(),
+		},
+	}
+
+	clientID, err := yk.addTouch(task)
+	if err != nil {
+		cancel()
+		return TouchStatus{}, err
+	}
+	task.status.ClientID = clientID
+
+	y.pruneTouchesLocked()
+	y.touches[touchID] = task
+
+	go func() {
+		err := task.run(ctx)
+		if errors.Is(err, context.Canceled) || errors.Is(err, ErrNoTouchPoint) {
+			return
+		}
+
+		y.ReportResult(yk, err)
+	}()
+	return task.Status(), nil
+}
+
+// TouchStatus returns the status of the touch scheduled by the client.
+func (y *YkMan) TouchStatus(clientID, touchID string) (TouchStatus, error) {
+	task, err := y.touchTask(clientID, touchID)
+	if err != nil {
+		return TouchStatus{}, err
+	}
+
+	return task.Status(), nil
+}
+
+// WaitTouch waits until the touch is finished or ctx is done, returning its latest status.
+func (y *YkMan) WaitTouch(ctx context.Context, clientID, touchID string) (TouchStatus, error) {
+	task, err := y.touchTask(clientID, touchID)
+	if err != nil {
+		return TouchStatus{}, err
+	}
+
+	select {
+	case <-ctx.Done():
+	case <-task.done:
+	}
+
+	return task.Status(), nil
+}
+
+// CancelTouch cancels the touch and waits until the toucher is released or ctx is done.
+// A press in progress is released early if the toucher supports it, no further presses are made.
+func (y *YkMan) CancelTouch(ctx context.Context, clientID, touchID string) (TouchStatus, error) {
+	task, err := y.touchTask(clientID, touchID)
+	if err != nil {
+		return TouchStatus{}, err
+	}
+
+	task.cancel()
+	return y.WaitTouch(ctx, clientID, touchID)
+}
+
+func (y *YkMan) touchTask(clientID, touchID string) (*touchTask, error) {
+	y.touchMu.Lock()
+	task, ok := y.touches[touchID]
+	y.touchMu.Unlock()
+
+	if !ok || task.Status().ClientID != clientID {
+		return nil, fmt.Errorf("touch %q: %w", touchID, ErrUnknownTouch)
+	}
+
+	return task, nil
+}
+
+func (y *YkMan) pruneTouchesLocked() {
+	for id, task := range y.touches {
+		status := task.Status()
+		if status.State.IsFinal() && time.Since(status.FinishedAt) >= touchRetention {
+			delete(y.touches, id)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package ykman_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/simulator"
+	"github.com/buglloc/yubictld/internal/touchctl"
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+// newTouchYkMan wires the simulated Yubikey to a recording toucher.
+func newTouchYkMan(t *testing.T) (*ykman.YkMan, *simulator.Toucher) {
+	t.Helper()
+
+	toucher := simulator.NewToucher(simulator.DefaultHubLocation)
+	yk, _ := newSimYkMan(t, 1, withToucher{toucher})
+	return yk, toucher
+}
+
+func waitTouch(t *testing.T, yk *ykman.YkMan, clientID, touchID string) ykman.TouchStatus {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status, err := yk.WaitTouch(ctx, clientID, touchID)
+	require.NoError(t, err)
+	require.True(t, status.State.IsFinal(), "touch is still %s", status.State)
+	return status
+}
+
+func TestTouch_Schedule(t *testing.T) {
+	yk, toucher := newTouchYkMan(t)
+	key := acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"a"}})
+
+	gesture, err := touchctl.Preset(touchctl.GesturePresetDouble)
+	require.NoError(t, err)
+
+	status, err := yk.ScheduleTouch("t1", key, gesture)
+	require.NoError(t, err)
+	require.Equal(t, "a", status.ClientID)
+	require.Equal(t, key.Serial(), status.Serial)
+
+	status = waitTouch(t, yk, "a", "t1")
+	require.Equal(t, ykman.TouchStateDone, status.State)
+	require.NoError(t, status.Err)
+
+	touches := toucher.Touches()
+	require.Len(t, touches, 2)
+	require.Equal(t, key.Port(), touches[0].Port)
+	require.GreaterOrEqual(t, touches[1].At.Sub(touches[0].At), 700*time.Millisecond)
+
+	// touches are private to their client
+	_, err = yk.TouchStatus("b", "t1")
+	require.ErrorIs(t, err, ykman.ErrUnknownTouch)
+}
+
+func TestTouch_NotLeased(t *testing.T) {
+	yk, _ := newTouchYkMan(t)
+
+	_, err := yk.ScheduleTouch("t1", yk.Devices()[0], touchctl.Gesture{{Duration: time.Millisecond}})
+	require.ErrorIs(t, err, ykman.ErrNotLeased)
+}
+
+func TestTouch_Cancel(t *testing.T) {
+	yk, toucher := newTouchYkMan(t)
+	key := acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"a"}})
+
+	_, err := yk.ScheduleTouch("t1", key, touchctl.Gesture{{Delay: time.Minute, Duration: time.Second}})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status, err := yk.CancelTouch(ctx, "a", "t1")
+	require.NoError(t, err)
+	require.Equal(t, ykman.TouchStateCanceled, status.State)
+	require.Empty(t, toucher.Touches())
+
+	// a canceled touch is not a failure of the key
+	require.Zero(t, key.Failures())
+}
+
+func TestTouch_ReleaseCancels(t *testing.T) {
+	yk, toucher := newTouchYkMan(t)
+	key := acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"a"}})
+
+	_, err := yk.ScheduleTouch("t1", key, touchctl.Gesture{{Delay: time.Minute, Duration: time.Second}})
+	require.NoError(t, err)
+
+	_, err = yk.Release("a")
+	require.NoError(t, err)
+
+	status := waitTouch(t, yk, "a", "t1")
+	require.Equal(t, ykman.TouchStateCanceled, status.State)
+	require.Empty(t, toucher.Touches())
+}
+
+func TestTouch_ToucherFailure(t *testing.T) {
+	yk, toucher := newTouchYkMan(t)
+	key := acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"a"}})
+	toucher.Fail(context.DeadlineExceeded)
+
+	_, err := yk.ScheduleTouch("t1", key, touchctl.Gesture{{Duration: time.Millisecond}})
+	require.NoError(t, err)
+
+	status := waitTouch(t, yk, "a", "t1")
+	require.Equal(t, ykman.TouchStateFailed, status.State)
+	require.ErrorIs(t, status.Err, context.DeadlineExceeded)
+	require.Eventually(t, func() bool {
+		return key.Failures() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestTouch_ScheduleIsIdempotent(t *testing.T) {
+	yk, toucher := newTouchYkMan(t)
+	key := acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"a"}})
+	gesture := touchctl.Gesture{{Duration: time.Millisecond}}
+
+	first, err := yk.ScheduleTouch("t1", key, gesture)
+	require.NoError(t, err)
+
+	retried, err := yk.ScheduleTouch("t1", key, gesture)
+	require.NoError(t, err)
+	require.Equal(t, first.ScheduledAt, retried.ScheduledAt)
+
+	waitTouch(t, yk, "a", "t1")
+	require.Len(t, toucher.Touches(), 1)
+
+	// the ID stays bound to its lease
+	_, err = yk.Release("a")
+	require.NoError(t, err)
+	key = acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"b"}})
+
+	_, err = yk.ScheduleTouch("t1", key, gesture)
+	require.ErrorIs(t, err, ykman.ErrTouchExists)
+}
@@ -0,0 +1,110 @@
+package ykman
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultSysfsRoot     = "/sys"
+	DefaultDevRoot       = "/dev"
+	DefaultPowerOffDelay = 2 * time.Second
+)
+
+// USBControl manages Yubikeys on the USB level through the kernel sysfs and usbfs interfaces.
+type USBControl struct {
+	sysfsRoot string
+	devRoot   string
+}
+
+func NewUSBControl(sysfsRoot, devRoot string) *USBControl {
+	if sysfsRoot == "" {
+		sysfsRoot = DefaultSysfsRoot
+	}
+
+	if devRoot == "" {
+		devRoot = DefaultDevRoot
+	}
+
+	return &USBControl{
+		sysfsRoot: sysfsRoot,
+		devRoot:   devRoot,
+	}
+}
+
+// SetPortPower switches power of the hub port the device at the given location is plugged into:
+// writing 1 to .../<hub>:1.0/<hub>-portN/disable powers the port off, 0 powers it back on.
+// Works only with hubs supporting per-port power switching.
+func (u *USBControl) SetPortPower(loc string, on bool) error {
+	hub := hubLocation(loc)
+	port := portLocation(loc)
+	if hub == "" || port == 0 {
+		return fmt.Errorf("device at %q is not behind a hub: %w", loc, ErrPowerUnsupported)
+	}
+
+	target := filepath.Join(
+		u.devicePath(hub), hub+":1.0", hub+"-port"+strconv.Itoa(port), "disable",
+	)
+
+	val := "1"
+	if on {
+		val = "0"
+	}
+
+	if err := os.WriteFile(target, []byte(val), 0o644); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("port of %s: %w", loc, ErrPowerUnsupported)
+		}
+
+		return fmt.Errorf("write %s: %w", target, err)
+	}
+
+	return nil
+}
+
+// Reset issues USBDEVFS_RESET on the usbfs node of the device at the given location.
+func (u *USBControl) Reset(loc string) error {
+	uevent, err := os.ReadFile(filepath.Join(u.devicePath(loc), "uevent"))
+	if err != nil {
+		return fmt.Errorf("read uevent of %s: %w", loc, err)
+	}
+
+	var devName string
+	for _, line := range strings.Split(string(uevent), "\n") {
+		if name, ok := strings.CutPrefix(line, "DEVNAME="); ok {
+			devName = name
+			break
+		}
+	}
+
+	if devName == "" {
+		return fmt.Errorf("no usbfs node for %s", loc)
+	}
+
+	return resetUSBDevice(filepath.Join(u.devRoot, devName))
+}
+
+// Reauthorize toggles the sysfs authorized attribute of the device at the given location,
+// so the kernel unbinds its drivers, deconfigures and then reconfigures it.
+func (u *USBControl) Reauthorize(loc string) error {
+	target := filepath.Join(u.devicePath(loc), "authorized")
+	if err := os.WriteFile(target, []byte("0"), 0o644); err != nil {
+		return fmt.Errorf("deauthorize %s: %w", loc, err)
+	}
+
+	if err := os.WriteFile(target, []byte("1"), 0o644); err != nil {
+		return fmt.Errorf("authorize %s: %w", loc, err)
+	}
+
+	return nil
+}
+
+func (u *USBControl) devicePath(loc string) string {
+	return filepath.Join(u.sysfsRoot, "bus", "usb", "devices", loc)
+}
@@ -0,0 +1,27 @@
+//go:build linux
+
+package ykman
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// USBDEVFS_RESET is _IO('U', 20)
+const usbdevfsReset = 0x5514
+
+func resetUSBDevice(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer func() { _ = f.Close() }()
+
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), usbdevfsReset, 0)
+	if errno != 0 {
+		return fmt.Errorf("USBDEVFS_RESET %s: %w", path, errno)
+	}
+
+	return nil
+}
@@ -0,0 +1,115 @@
+package ykman_test
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+// watchSysfs replaces the sysfs attribute with a FIFO and reports every value written to it.
+func watchSysfs(t *testing.T, root, name string) <-chan string {
+	t.Helper()
+
+	path := filepath.Join(root, "bus", "usb", "devices", name)
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, syscall.Mkfifo(path, 0o644))
+
+	stop := make(chan struct{})
+	out := make(chan string, 16)
+	go func() {
+		for {
+			f, err := os.Open(path)
+			if err != nil {
+				return
+			}
+
+			data, _ := io.ReadAll(f)
+			_ = f.Close()
+			for _, b := range data {
+				out <- string(b)
+			}
+
+			select {
+			case <-stop:
+				return
+			default:
+			}
+		}
+	}()
+
+	t.Cleanup(func() {
+		close(stop)
+		// unblock the reader waiting for the next writer
+		if f, err := os.OpenFile(path, os.O_WRONLY|syscall.O_NONBLOCK, 0); err == nil {
+			_ = f.Close()
+		}
+	})
+
+	return out
+}
+
+func TestUSB_Reauthorize(t *testing.T) {
+	root := t.TempDir()
+	written := watchSysfs(t, root, "1-1.1/authorized")
+
+	usb := ykman.NewUSBControl(root, t.TempDir())
+	require.NoError(t, usb.Reauthorize("1-1.1"))
+	require.Equal(t, "0", <-written)
+	require.Equal(t, "1", <-written)
+}
+
+func TestUSB_ResetOpensUsbfsNode(t *testing.T) {
+	root := writeSysfs(t, map[string]string{
+		"1-1.1/uevent": "MAJOR=189\nMINOR=3\nDEVNAME=bus/usb/001/004\nDEVTYPE=usb_device\n",
+	})
+
+	// a regular file stands for the usbfs node, which rejects the ioctl
+	devRoot := t.TempDir()
+	node := filepath.Join(devRoot, "bus", "usb", "001", "004")
+	require.NoError(t, os.MkdirAll(filepath.Dir(node), 0o755))
+	require.NoError(t, os.WriteFile(node, nil, 0o644))
+
+	err := ykman.NewUSBControl(root, devRoot).Reset("1-1.1")
+	require.ErrorContains(t, err, "USBDEVFS_RESET "+node)
+	require.ErrorIs(t, err, syscall.ENOTTY)
+}
+
+func TestReboot_Deauthorize(t *testing.T) {
+	root := t.TempDir()
+	written := watchSysfs(t, root, "1-1.1/authorized")
+	yk, sim := newSimYkMan(t, 1, ykman.WithSysfsRoot(root))
+	key := yk.Devices()[0]
+	dev := sim.Devices()[0]
+
+	// the kernel deconfigures the device once it is deauthorized
+	go func() {
+		if <-written == "0" {
+			_ = dev.Reboot()
+		}
+		<-written
+	}()
+
+	_, err := yk.Reboot(context.Background(), key, ykman.RebootStrategyKindDeauthorize)
+	require.NoError(t, err)
+	require.Equal(t, dev.Path(), key.Path())
+	require.Zero(t, key.Failures())
+}
+
+func TestReboot_USBReset(t *testing.T) {
+	root := writeSysfs(t, map[string]string{"1-1.1/uevent": "DEVNAME=bus/usb/001/002\n"})
+	yk, sim := newSimYkMan(t, 1, ykman.WithSysfsRoot(root), ykman.WithDevRoot(t.TempDir()))
+	key := yk.Devices()[0]
+
+	// the strategy goes for the usbfs node, not the FIDO reboot
+	_, err := yk.Reboot(context.Background(), key, ykman.RebootStrategyKindUSBReset)
+	require.ErrorContains(t, err, "bus/usb/001/002")
+	require.Zero(t, sim.Devices()[0].Reboots())
+	require.Equal(t, 1, key.Failures())
+}
@@ -0,0 +1,12 @@
+//go:build !linux
+
+package ykman
+
+import (
+	"fmt"
+	"runtime"
+)
+
+func resetUSBDevice(_ string) error {
+	return fmt.Errorf("USB reset on %s: %w", runtime.GOOS, ErrResetUnsupported)
+}
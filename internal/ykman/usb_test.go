@@ -0,0 +1,129 @@
+package ykman_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+// writeSysfs creates the files of a fake sysfs tree, relative to the USB devices directory.
+func writeSysfs(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, "bus", "usb", "devices", name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+
+	return root
+}
+
+func readSysfs(t *testing.T, root, name string) string {
+	t.Helper()
+
+	content, err := os.ReadFile(filepath.Join(root, "bus", "usb", "devices", name))
+	require.NoError(t, err)
+	return string(content)
+}
+
+func TestUSB_SetPortPower(t *testing.T) {
+	const portFile = "1-2/1-2:1.0/1-2-port3/disable"
+
+	cases := []struct {
+		name    string
+		loc     string
+		files   map[string]string
+		on      bool
+		want    string
+		wantErr error
+	}{
+		{
+			name:  "off",
+			loc:   "1-2.3",
+			files: map[string]string{portFile: "0"},
+			want:  "1",
+		},
+		{
+			name:  "on",
+			loc:   "1-2.3",
+			files: map[string]string{portFile: "1"},
+			on:    true,
+			want:  "0",
+		},
+		{
+			name:    "not behind a hub",
+			loc:     "1-2",
+			files:   map[string]string{portFile: "0"},
+			wantErr: ykman.ErrPowerUnsupported,
+		},
+		{
+			name:    "no port switching",
+			loc:     "1-2.3",
+			files:   map[string]string{"1-2/1-2:1.0/1-2-port1/disable": "0"},
+			wantErr: ykman.ErrPowerUnsupported,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := writeSysfs(t, tc.files)
+			usb := ykman.NewUSBControl(root, t.TempDir())
+
+			err := usb.SetPortPower(tc.loc, tc.on)
+			if tc.wantErr != nil {
+				require.ErrorIs(t, err, tc.wantErr)
+				for name, content := range tc.files {
+					require.Equal(t, content, readSysfs(t, root, name))
+				}
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, tc.want, readSysfs(t, root, portFile))
+		})
+	}
+}
+
+func TestUSB_PowerCycleUnsupported(t *testing.T) {
+	// the hub of the simulated keys has no per-port power switching
+	root := writeSysfs(t, map[string]string{"1-1/1-1:1.0/1-1-port9/disable": "0"})
+	yk, _ := newSimYkMan(t, 1, ykman.WithSysfsRoot(root))
+	key := yk.Devices()[0]
+
+	_, err := yk.PowerCycle(context.Background(), key)
+	require.ErrorIs(t, err, ykman.ErrPowerUnsupported)
+	require.Zero(t, key.Failures())
+}
+
+func TestUSB_ResetUsbfsNode(t *testing.T) {
+	cases := []struct {
+		name    string
+		files   map[string]string
+		wantErr string
+	}{
+		{
+			name:    "unknown device",
+			files:   map[string]string{"1-1.2/uevent": "DEVNAME=bus/usb/001/004\n"},
+			wantErr: "read uevent of 1-1.1",
+		},
+		{
+			name:    "no usbfs node",
+			files:   map[string]string{"1-1.1/uevent": "MAJOR=189\nMINOR=3\nDEVTYPE=usb_device\n"},
+			wantErr: "no usbfs node for 1-1.1",
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			usb := ykman.NewUSBControl(writeSysfs(t, tc.files), t.TempDir())
+			require.ErrorContains(t, usb.Reset("1-1.1"), tc.wantErr)
+		})
+	}
+}
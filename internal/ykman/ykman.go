@@ -1,30 +1,88 @@
 package ykman
 
 import (
+	"container/list"
+	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
-	"github.com/buglloc/fidoctl"
 	"github.com/rs/zerolog/log"
 )
 
 type YkMan struct {
-	lockTTL   time.Duration
-	discovery Discovery
-	mu        sync.Mutex
-	store     []*Yubikey
+	lockTTL         time.Duration
+	maxLockTTL      time.Duration
+	maxLifetime     time.Duration
+	reapInterval    time.Duration
+	missingGrace    time.Duration
+	maxFailures     int
+	healthInterval  time.Duration
+	releasePolicies []ReleasePolicy
+	cleanupTimeout  time.Duration
+	rebootTimeout   time.Duration
+	sysfsRoot       string
+	devRoot         string
+	usb             *USBControl
+	powerOffDelay   time.Duration
+	enumerator      Enumerator
+	discovery       Discovery
+	leases          LeaseStore
+	mu              sync.Mutex
+	store           []*Yubikey
+	waiters         *list.List
+	pendingLeases   map[uint32]Lease
+	touchMu         sync.Mutex
+	touches         map[string]*touchTask
+	closed          chan struct{}
+	closeOnce       sync.Once
+}
+
+type AcquireReq struct {
+	GroupID     string
+	ClientIDs   []string
+	Selector    Selector
+	Constraints Constraints
+	TTL         time.Duration
+	MaxLifetime time.Duration
+}
+
+type waiter struct {
+	req   AcquireReq
+	yks   []*Yubikey
+	ready chan struct{}
 }
 
 func NewYkMan(opts ...Option) *YkMan {
 	yk := &YkMan{
-		lockTTL: DefaultLockTTL,
+		lockTTL:        DefaultLockTTL,
+		reapInterval:   DefaultReapInterval,
+		missingGrace:   DefaultMissingGrace,
+		maxFailures:    DefaultMaxFailures,
+		cleanupTimeout: DefaultCleanupTimeout,
+		rebootTimeout:  DefaultRebootTimeout,
+		powerOffDelay:  DefaultPowerOffDelay,
+		enumerator:     NewHIDEnumerator(),
+		leases:         NewMemoryLeaseStore(),
+		waiters:        list.New(),
+		touches:        make(map[string]*touchTask),
+		closed:         make(chan struct{}),
 	}
 
 	for _, opt := range opts {
 		opt(yk)
 	}
 
+	yk.usb = NewUSBControl(yk.sysfsRoot, yk.devRoot)
+
+	if yk.reapInterval > 0 {
+		go yk.reapLoop()
+	}
+
+	if yk.healthInterval > 0 {
+		go yk.healthLoop()
+	}
 	return yk
 }
 
@@ -32,68 +90,462 @@ func (y *YkMan) ReloadDevices() error {
 	y.mu.Lock()
 	defer y.mu.Unlock()
 
-	devices, err := fidoctl.Enumerate()
+	devices, err := y.enumerator.Enumerate()
 	if err != nil {
 		return fmt.Errorf("enumerate devices: %w", err)
 	}
 
-	y.store = y.store[:0]
+	seen := make(map[uint32]struct{}, len(devices))
 	for _, dev := range devices {
-		yk, err := newYubikey(dev, y.discovery)
+		yk, err := newYubikey(y.enumerator, dev, y.discovery)
 		if err != nil {
-			return fmt.Errorf("create yubikey %s: %w", dev.String(), err)
+			// a busy device, e.g. a card opened exclusively by the lease holder, is still plugged in
+			if known := y.byPathLocked(dev.Path()); known != nil {
+				seen[known.serial] = struct{}{}
+				continue
+			}
+
+			log.Warn().
+				Err(err).
+				Str("device", dev.String()).
+				Msg("skip unidentifiable device")
+			continue
 		}
 
 		if y.discovery != nil && yk.Port() == 0 {
 			continue
 		}
 
-		y.store = append(y.store, yk)
+		seen[yk.serial] = struct{}{}
+		y.attachLocked(yk)
+	}
+
+	for _, yk := range append([]*Yubikey(nil), y.store...) {
+		if _, ok := seen[yk.serial]; !ok {
+			y.detachLocked(yk)
+		}
+	}
+
+	if err := y.restoreLeasesLocked(); err != nil {
+		return fmt.Errorf("restore leases: %w", err)
 	}
 
+	y.dispatchLocked()
 	return nil
 }
 
-func (y *YkMan) Acquire(clientID string) (*Yubikey, error) {
+// AddDevice adds a single device by its hidraw path to the pool.
+// If a Yubikey with the same serial is already known, its device handle is refreshed instead.
+func (y *YkMan) AddDevice(path string) (*Yubikey, error) {
+	devices, err := y.enumerator.Enumerate()
+	if err != nil {
+		return nil, fmt.Errorf("enumerate devices: %w", err)
+	}
+
+	var dev Device
+	for _, d := range devices {
+		if d.Path() == path {
+			dev = d
+			break
+		}
+	}
+
+	if dev == nil {
+		return nil, fmt.Errorf("lookup %s: %w", path, ErrNotYubikey)
+	}
+
+	yk, err := newYubikey(y.enumerator, dev, y.discovery)
+	if err != nil {
+		return nil, fmt.Errorf("create yubikey %s: %w", dev.String(), err)
+	}
+
+	if y.discovery != nil && yk.Port() == 0 {
+		return nil, fmt.Errorf("add %s: %w", yk, ErrUnmanaged)
+	}
+
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	yk = y.attachLocked(yk)
+	if err := y.restoreLeasesLocked(); err != nil {
+		return yk, fmt.Errorf("restore leases: %w", err)
+	}
+
+	y.dispatchLocked()
+	return yk, nil
+}
+
+// RemoveDevice detaches the device with the given hidraw path from the pool.
+// A leased Yubikey is kept as missing for the grace period, so the lease survives a replug.
+func (y *YkMan) RemoveDevice(path string) (*Yubikey, bool) {
 	y.mu.Lock()
 	defer y.mu.Unlock()
 
 	for _, yk := range y.store {
-		if !yk.IsFree() {
-			if time.Since(yk.lastAccess) < y.lockTTL {
-				continue
+		if yk.Path() != path {
+			continue
+		}
+
+		y.detachLocked(yk)
+		return yk, true
+	}
+
+	return nil, false
+}
+
+// TryAcquire atomically acquires one free Yubikey per client ID of the request without waiting.
+func (y *YkMan) TryAcquire(req AcquireReq) ([]*Yubikey, error) {
+	req = y.normalizeReq(req)
+
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	y.reapLocked()
+	reserved := y.dispatchLocked()
+	return y.acquireLocked(req, reserved)
+}
+
+// Acquire atomically acquires one free Yubikey per client ID of the request,
+// waiting in a FIFO queue until enough keys are freed or ctx is done.
+func (y *YkMan) Acquire(ctx context.Context, req AcquireReq) ([]*Yubikey, error) {
+	req = y.normalizeReq(req)
+
+	y.mu.Lock()
+	y.reapLocked()
+	reserved := y.dispatchLocked()
+	yks, err := y.acquireLocked(req, reserved)
+	if !errors.Is(err, ErrNoFreeYubikey) {
+		y.mu.Unlock()
+		return yks, err
+	}
+
+	w := &waiter{
+		req:   req,
+		ready: make(chan struct{}),
+	}
+	elem := y.waiters.PushBack(w)
+	y.mu.Unlock()
+
+	log.Info().
+		Str("group_id", req.GroupID).
+		Strs("client_ids", req.ClientIDs).
+		Msg("waiting for free yubikeys")
+
+	select {
+	case <-w.ready:
+		return w.yks, nil
+	case <-ctx.Done():
+	}
+
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if w.yks == nil {
+		y.waiters.Remove(elem)
+		return nil, fmt.Errorf("%w: %w", ErrNoFreeYubikey, ctx.Err())
+	}
+
+	// we were granted keys right after giving up, so give them back
+	for _, yk := range w.yks {
+		if err := yk.Release(); err != nil {
+			log.Error().
+				Err(err).
+				Uint32("yk_serial", yk.serial).
+				Msg("release of abandoned yubikey failed")
+			continue
+		}
+
+		y.deleteLeaseLocked(yk)
+	}
+
+	y.dispatchLocked()
+	return nil, fmt.Errorf("%w: %w", ErrNoFreeYubikey, ctx.Err())
+}
+
+// Release releases the Yubikey held by the client, or every Yubikey of the group.
+func (y *YkMan) Release(leaseID string) ([]*Yubikey, error) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	yks, err := y.leaseLocked(leaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, yk := range yks {
+		if err := yk.Release(); err != nil {
+			errs = append(errs, fmt.Errorf("release %s: %w", yk, err))
+			continue
+		}
+
+		y.deleteLeaseLocked(yk)
+		if yk.IsMissing() {
+			y.removeLocked(yk)
+			continue
+		}
+
+		y.sanitizeLocked(yk)
+	}
+
+	y.dispatchLocked()
+	return yks, errors.Join(errs...)
+}
+
+// Ping extends the lease of the client, or of every Yubikey of the group.
+func (y *YkMan) Ping(leaseID string) ([]*Yubikey, error) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	yks, err := y.leaseLocked(leaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, yk := range yks {
+		if err := yk.Ping(); err != nil {
+			return yks, fmt.Errorf("ping %s: %w", yk, err)
+		}
+
+		if err := y.leases.Save(yk.Lease()); err != nil {
+			log.Error().
+				Err(err).
+				Uint32("yk_serial", yk.serial).
+				Msg("save lease failed")
+		}
+	}
+
+	return yks, nil
+}
+
+// Lease returns the Yubikey held by the client, or every Yubikey of the group.
+func (y *YkMan) Lease(leaseID string) ([]*Yubikey, error) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.leaseLocked(leaseID)
+}
+
+func (y *YkMan) ForClient(clientID string) (*Yubikey, error) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.forClientLocked(clientID)
+}
+
+func (y *YkMan) RebootTimeout() time.Duration {
+	return y.rebootTimeout
+}
+
+// Reboot reboots the Yubikey with the given strategy, waiting for it to come back, and records the result for its health.
+func (y *YkMan) Reboot(ctx context.Context, yk *Yubikey, strategy RebootStrategyKind) (time.Duration, error) {
+	var downtime time.Duration
+	var err error
+	switch strategy {
+	case RebootStrategyKindFIDO, "":
+		downtime, err = yk.Reboot(ctx, y.rebootTimeout)
+	case RebootStrategyKindUSBReset:
+		downtime, err = yk.USBReset(ctx, y.usb, y.rebootTimeout)
+	case RebootStrategyKindDeauthorize:
+		downtime, err = yk.Deauthorize(ctx, y.usb, y.rebootTimeout)
+	default:
+		return 0, fmt.Errorf("unsupported reboot strategy: %s", strategy)
+	}
+
+	if !errors.Is(err, ErrResetUnsupported) {
+		y.ReportResult(yk, err)
+	}
+	return downtime, err
+}
+
+func (y *YkMan) PowerOffDelay() time.Duration {
+	return y.powerOffDelay
+}
+
+// PowerCycle power cycles the Yubikey hub port, waiting for it to come back, and records the result for its health.
+func (y *YkMan) PowerCycle(ctx context.Context, yk *Yubikey) (time.Duration, error) {
+	downtime, err := yk.PowerCycle(ctx, y.usb, y.powerOffDelay, y.rebootTimeout)
+	if !errors.Is(err, ErrPowerUnsupported) {
+		y.ReportResult(yk, err)
+	}
+	return downtime, err
+}
+
+func (y *YkMan) Devices() []*Yubikey {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	out := make([]*Yubikey, len(y.store))
+	copy(out, y.store)
+	return out
+}
+
+func (y *YkMan) Close() error {
+	y.closeOnce.Do(func() {
+		close(y.closed)
+	})
+
+	return y.leases.Close()
+}
+
+func (y *YkMan) reapLoop() {
+	ticker := time.NewTicker(y.reapInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-y.closed:
+			return
+		case <-ticker.C:
+			y.mu.Lock()
+			if y.reapLocked() {
+				y.dispatchLocked()
 			}
+			y.mu.Unlock()
+		}
+	}
+}
 
+func (y *YkMan) reapLocked() bool {
+	reaped := false
+	for _, yk := range append([]*Yubikey(nil), y.store...) {
+		if missingSince := yk.MissingSince(); !missingSince.IsZero() && time.Since(missingSince) >= y.missingGrace {
 			log.Warn().
 				Uint32("yk_serial", yk.serial).
-				Time("last_access", yk.lastAccess).
-				Dur("stale_time", time.Since(yk.lastAccess)).
-				Msg("release stale lock")
+				Time("missing_since", missingSince).
+				Msg("drop missing yubikey")
 
 			if err := yk.Release(); err != nil {
 				log.Error().
 					Uint32("yk_serial", yk.serial).
-					Time("last_access", yk.lastAccess).
 					Msg("release failed")
 				continue
 			}
+
+			y.deleteLeaseLocked(yk)
+			y.removeLocked(yk)
+			continue
 		}
 
-		err := yk.Acquire(clientID)
-		if err != nil {
+		if yk.IsFree() {
 			continue
 		}
 
-		return yk, nil
+		lease := yk.Lease()
+		switch {
+		case time.Since(lease.LastAccess) >= y.leaseTTL(lease):
+			log.Warn().
+				Uint32("yk_serial", yk.serial).
+				Time("last_access", lease.LastAccess).
+				Dur("stale_time", time.Since(lease.LastAccess)).
+				Msg("release stale lock")
+
+		case lease.MaxLifetime > 0 && time.Since(lease.AcquiredAt) >= lease.MaxLifetime:
+			log.Warn().
+				Uint32("yk_serial", yk.serial).
+				Time("acquired_at", lease.AcquiredAt).
+				Dur("max_lifetime", lease.MaxLifetime).
+				Msg("release expired lock")
+
+		default:
+			continue
+		}
+
+		if err := yk.Release(); err != nil {
+			log.Error().
+				Uint32("yk_serial", yk.serial).
+				Time("last_access", yk.LastAccess()).
+				Msg("release failed")
+			continue
+		}
+
+		y.deleteLeaseLocked(yk)
+		if yk.IsMissing() {
+			y.removeLocked(yk)
+			continue
+		}
+
+		y.sanitizeLocked(yk)
+		reaped = true
 	}
 
-	return nil, ErrNoFreeYubikey
+	return reaped
 }
 
-func (y *YkMan) ForClient(clientID string) (*Yubikey, error) {
-	y.mu.Lock()
-	defer y.mu.Unlock()
+// dispatchLocked hands freed keys to the waiters in FIFO order.
+// Free keys wanted by a waiter that is still not satisfied are returned as reserved,
+// so that neither later waiters nor newcomers can starve it.
+func (y *YkMan) dispatchLocked() map[*Yubikey]struct{} {
+	reserved := make(map[*Yubikey]struct{})
+	for e := y.waiters.Front(); e != nil; {
+		next := e.Next()
+		w := e.Value.(*waiter)
+
+		yks, err := y.acquireLocked(w.req, reserved)
+		if err == nil {
+			w.yks = yks
+			y.waiters.Remove(e)
+			close(w.ready)
+		} else {
+			for _, yk := range y.store {
+				if yk.IsFree() && w.req.Selector.Match(yk) {
+					reserved[yk] = struct{}{}
+				}
+			}
+		}
 
+		e = next
+	}
+
+	return reserved
+}
+
+func (y *YkMan) acquireLocked(req AcquireReq, reserved map[*Yubikey]struct{}) ([]*Yubikey, error) {
+	var matched, free []*Yubikey
+	for _, yk := range y.store {
+		if !req.Selector.Match(yk) {
+			continue
+		}
+
+		matched = append(matched, yk)
+		if _, ok := reserved[yk]; ok {
+			continue
+		}
+
+		if yk.IsFree() && !yk.IsMissing() && !yk.IsQuarantined() && !yk.IsCleaning() && !yk.isProbing() {
+			free = append(free, yk)
+		}
+	}
+
+	if req.Constraints.pick(matched, len(req.ClientIDs)) == nil {
+		return nil, ErrNoMatchingYubikey
+	}
+
+	picked := req.Constraints.pick(free, len(req.ClientIDs))
+	if picked == nil {
+		return nil, ErrNoFreeYubikey
+	}
+
+	for i, yk := range picked {
+		err := yk.Acquire(req.ClientIDs[i], req.GroupID, req.TTL, req.MaxLifetime)
+		if err == nil {
+			err = y.leases.Save(yk.Lease())
+		}
+
+		if err != nil {
+			for _, acquired := range picked[:i+1] {
+				_ = acquired.Release()
+				y.deleteLeaseLocked(acquired)
+			}
+
+			return nil, fmt.Errorf("acquire %s: %w", yk, err)
+		}
+	}
+
+	return picked, nil
+}
+
+func (y *YkMan) forClientLocked(clientID string) (*Yubikey, error) {
 	for _, yk := range y.store {
 		if yk.client != clientID {
 			continue
@@ -105,11 +557,177 @@ func (y *YkMan) ForClient(clientID string) (*Yubikey, error) {
 	return nil, fmt.Errorf("get Yubikey for client %s: %w", clientID, ErrNoAssociated)
 }
 
-func (y *YkMan) Devices() []*Yubikey {
-	y.mu.Lock()
-	defer y.mu.Unlock()
+func (y *YkMan) leaseLocked(leaseID string) ([]*Yubikey, error) {
+	var out []*Yubikey
+	for _, yk := range y.store {
+		if yk.client != leaseID && (yk.group == "" || yk.group != leaseID) {
+			continue
+		}
 
-	out := make([]*Yubikey, len(y.store))
-	copy(out, y.store)
-	return out
+		out = append(out, yk)
+	}
+
+	if len(out) == 0 {
+		return nil, fmt.Errorf("get Yubikeys for lease %s: %w", leaseID, ErrNoAssociated)
+	}
+
+	return out, nil
+}
+
+// restoreLeasesLocked reattaches the leases persisted by the previous run to their Yubikeys.
+// The store is loaded once, leases whose Yubikeys are not seen yet are kept pending until they lapse.
+func (y *YkMan) restoreLeasesLocked() error {
+	if y.pendingLeases == nil {
+		leases, err := y.leases.Load()
+		if err != nil {
+			return err
+		}
+
+		y.pendingLeases = make(map[uint32]Lease, len(leases))
+		for _, lease := range leases {
+			// leases saved by older versions have no limits of their own
+			lease.TTL = y.leaseTTL(lease)
+			if lease.AcquiredAt.IsZero() {
+				lease.AcquiredAt = lease.LastAccess
+			}
+
+			y.pendingLeases[lease.Serial] = lease
+		}
+	}
+
+	for serial, lease := range y.pendingLeases {
+		if time.Now().After(lease.ExpiresAt()) {
+			log.Warn().
+				Uint32("yk_serial", lease.Serial).
+				Time("last_access", lease.LastAccess).
+				Time("acquired_at", lease.AcquiredAt).
+				Msg("drop expired lease")
+
+			delete(y.pendingLeases, serial)
+			if err := y.leases.Delete(serial); err != nil {
+				return fmt.Errorf("delete expired lease: %w", err)
+			}
+			continue
+		}
+
+		for _, yk := range y.store {
+			if yk.serial != serial {
+				continue
+			}
+
+			delete(y.pendingLeases, serial)
+			if !yk.IsFree() {
+				break
+			}
+
+			yk.restore(lease)
+			log.Info().
+				Uint32("yk_serial", lease.Serial).
+				Str("client_id", lease.ClientID).
+				Str("group_id", lease.GroupID).
+				Msg("lease restored")
+			break
+		}
+	}
+
+	return nil
+}
+
+func (y *YkMan) deleteLeaseLocked(yk *Yubikey) {
+	if err := y.leases.Delete(yk.serial); err != nil {
+		log.Error().
+			Err(err).
+			Uint32("yk_serial", yk.serial).
+			Msg("delete lease failed")
+	}
+}
+
+// attachLocked adds a freshly discovered Yubikey to the pool, or refreshes the device handle
+// of the known one with the same serial, so its lease is kept.
+func (y *YkMan) attachLocked(yk *Yubikey) *Yubikey {
+	for _, existing := range y.store {
+		if existing.serial != yk.serial {
+			continue
+		}
+
+		if existing.IsMissing() {
+			log.Info().
+				Uint32("yk_serial", yk.serial).
+				Str("path", yk.Path()).
+				Msg("missing yubikey is back")
+		}
+
+		existing.attach(yk)
+		return existing
+	}
+
+	y.store = append(y.store, yk)
+	return yk
+}
+
+func (y *YkMan) detachLocked(yk *Yubikey) {
+	if yk.IsFree() && !yk.IsCleaning() {
+		y.removeLocked(yk)
+		return
+	}
+
+	if yk.IsMissing() {
+		return
+	}
+
+	log.Warn().
+		Uint32("yk_serial", yk.serial).
+		Str("path", yk.Path()).
+		Dur("grace", y.missingGrace).
+		Msg("leased yubikey is missing")
+
+	yk.markMissing()
+}
+
+func (y *YkMan) byPathLocked(path string) *Yubikey {
+	for _, yk := range y.store {
+		if !yk.IsMissing() && yk.Path() == path {
+			return yk
+		}
+	}
+
+	return nil
+}
+
+func (y *YkMan) removeLocked(yk *Yubikey) {
+	for i, existing := range y.store {
+		if existing == yk {
+			y.store = append(y.store[:i], y.store[i+1:]...)
+			return
+		}
+	}
+}
+
+// normalizeReq applies the default lease TTL and clamps the requested limits by the configured bounds.
+func (y *YkMan) normalizeReq(req AcquireReq) AcquireReq {
+	if req.TTL <= 0 {
+		req.TTL = y.lockTTL
+	}
+
+	if y.maxLockTTL > 0 && req.TTL > y.maxLockTTL {
+		req.TTL = y.maxLockTTL
+	}
+
+	if req.TTL < MinLockTTL {
+		req.TTL = MinLockTTL
+	}
+
+	if y.maxLifetime > 0 && (req.MaxLifetime <= 0 || req.MaxLifetime > y.maxLifetime) {
+		req.MaxLifetime = y.maxLifetime
+	}
+
+	return req
+}
+
+func (y *YkMan) leaseTTL(lease Lease) time.Duration {
+	if lease.TTL > 0 {
+		return lease.TTL
+	}
+
+	return y.lockTTL
 }
@@ -0,0 +1,268 @@
+package ykman_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/internal/simulator"
+	"github.com/buglloc/yubictld/internal/touchctl"
+	"github.com/buglloc/yubictld/internal/ykman"
+)
+
+// withToucher wires every simulated Yubikey to its own port of the toucher.
+type withToucher struct {
+	toucher touchctl.Toucher
+}
+
+// newSimYkMan runs a YkMan over count simulated Yubikeys until the test ends.
+// Takes simulator and ykman options as well as withToucher. The simulated Yubikeys reboot instantly by default.
+func newSimYkMan(t *testing.T, count int, opts ...any) (*ykman.YkMan, *simulator.Enumerator) {
+	t.Helper()
+
+	simOpts := []simulator.Option{simulator.WithRebootLatency(0)}
+	ykOpts := []ykman.Option{ykman.WithReapInterval(0)}
+	var toucher touchctl.Toucher
+	for _, opt := range opts {
+		switch v := opt.(type) {
+		case simulator.Option:
+			simOpts = append(simOpts, v)
+		case ykman.Option:
+			ykOpts = append(ykOpts, v)
+		case withToucher:
+			toucher = v.toucher
+		default:
+			t.Fatalf("unexpected option: %T", opt)
+		}
+	}
+
+	sim := simulator.NewEnumerator(count, simOpts...)
+	ykOpts = append(ykOpts, ykman.WithEnumerator(sim))
+	if toucher != nil {
+		points := make(map[uint32]ykman.TouchPoint)
+		for serial, port := range sim.Ports() {
+			points[serial] = ykman.TouchPoint{ToucherID: "sim", Toucher: toucher, Port: port}
+		}
+
+		discovery, err := ykman.NewManualDiscovery(points)
+		require.NoError(t, err)
+		ykOpts = append(ykOpts, ykman.WithDiscovery(discovery))
+	}
+
+	yk := ykman.NewYkMan(ykOpts...)
+	t.Cleanup(func() {
+		_ = yk.Close()
+	})
+	require.NoError(t, yk.ReloadDevices())
+
+	return yk, sim
+}
+
+func acquireOne(t *testing.T, yk *ykman.YkMan, req ykman.AcquireReq) *ykman.Yubikey {
+	t.Helper()
+
+	acquired, err := yk.TryAcquire(req)
+	require.NoError(t, err)
+	require.Len(t, acquired, 1)
+
+	return acquired[0]
+}
+
+type acquireResult struct {
+	yks []*ykman.Yubikey
+	err error
+}
+
+// acquireAsync starts a waiting acquire and gives it some time to queue up.
+func acquireAsync(ctx context.Context, yk *ykman.YkMan, req ykman.AcquireReq) <-chan acquireResult {
+	out := make(chan acquireResult, 1)
+	go func() {
+		yks, err := yk.Acquire(ctx, req)
+		out <- acquireResult{yks: yks, err: err}
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	return out
+}
+
+func waitAcquired(t *testing.T, ch <-chan acquireResult) []*ykman.Yubikey {
+	t.Helper()
+
+	select {
+	case res := <-ch:
+		require.NoError(t, res.err)
+		return res.yks
+	case <-time.After(5 * time.Second):
+		t.Fatal("acquire is still waiting")
+		return nil
+	}
+}
+
+func requireWaiting(t *testing.T, ch <-chan acquireResult) {
+	t.Helper()
+
+	select {
+	case res := <-ch:
+		t.Fatalf("acquire is done: %v", res.err)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestAcquire_FIFO(t *testing.T) {
+	yk, _ := newSimYkMan(t, 1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	held := acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"a"}})
+	second := acquireAsync(ctx, yk, ykman.AcquireReq{ClientIDs: []string{"b"}})
+	third := acquireAsync(ctx, yk, ykman.AcquireReq{ClientIDs: []string{"c"}})
+
+	_, err := yk.Release("a")
+	require.NoError(t, err)
+	require.Equal(t, held, waitAcquired(t, second)[0])
+	requireWaiting(t, third)
+
+	_, err = yk.Release("b")
+	require.NoError(t, err)
+	require.Equal(t, held, waitAcquired(t, third)[0])
+
+	owner, err := yk.ForClient("c")
+	require.NoError(t, err)
+	require.Equal(t, held, owner)
+}
+
+func TestAcquire_WaiterIsNotStarved(t *testing.T) {
+	yk, _ := newSimYkMan(t, 2)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"a"}})
+	group := acquireAsync(ctx, yk, ykman.AcquireReq{GroupID: "g", ClientIDs: []string{"g1", "g2"}})
+
+	// the remaining free key is kept for the group waiting ahead
+	_, err := yk.TryAcquire(ykman.AcquireReq{ClientIDs: []string{"newcomer"}})
+	require.ErrorIs(t, err, ykman.ErrNoFreeYubikey)
+
+	_, err = yk.Release("a")
+	require.NoError(t, err)
+	require.Len(t, waitAcquired(t, group), 2)
+
+	released, err := yk.Release("g")
+	require.NoError(t, err)
+	require.Len(t, released, 2)
+}
+
+func TestAcquire_WaitTimeout(t *testing.T) {
+	yk, _ := newSimYkMan(t, 1)
+	acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"a"}})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err := yk.Acquire(ctx, ykman.AcquireReq{ClientIDs: []string{"b"}})
+	require.ErrorIs(t, err, ykman.ErrNoFreeYubikey)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	// the timed out waiter doesn't hold the key once it is released
+	_, err = yk.Release("a")
+	require.NoError(t, err)
+	acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"c"}})
+}
+
+func TestLease_TTL(t *testing.T) {
+	t.Parallel()
+	yk, _ := newSimYkMan(t, 1)
+
+	// too short TTLs are raised, so the recommended pings keep the lease
+	held := acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"a"}, TTL: time.Millisecond})
+	require.Equal(t, ykman.MinLockTTL, held.Lease().TTL)
+	require.Equal(t, ykman.MinPingInterval, held.Lease().PingInterval())
+
+	time.Sleep(2 * time.Second)
+	_, err := yk.Ping("a")
+	require.NoError(t, err)
+
+	// the ping keeps the lease past the initial TTL
+	time.Sleep(2 * time.Second)
+	_, err = yk.TryAcquire(ykman.AcquireReq{ClientIDs: []string{"b"}})
+	require.ErrorIs(t, err, ykman.ErrNoFreeYubikey)
+
+	// and it lapses without them
+	time.Sleep(1200 * time.Millisecond)
+	require.Equal(t, held, acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"b"}}))
+
+	_, err = yk.ForClient("a")
+	require.ErrorIs(t, err, ykman.ErrNoAssociated)
+}
+
+func TestLease_MaxLifetime(t *testing.T) {
+	yk, _ := newSimYkMan(t, 1, ykman.WithMaxLifetime(300*time.Millisecond))
+
+	held := acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"a"}, TTL: time.Hour})
+	require.Equal(t, 300*time.Millisecond, held.Lease().MaxLifetime)
+
+	// pings don't extend the lease beyond its lifetime
+	require.Eventually(t, func() bool {
+		_, _ = yk.Ping("a")
+		_, err := yk.TryAcquire(ykman.AcquireReq{ClientIDs: []string{"b"}})
+		return err == nil
+	}, 5*time.Second, 50*time.Millisecond)
+
+	_, err := yk.ForClient("a")
+	require.ErrorIs(t, err, ykman.ErrNoAssociated)
+}
+
+func TestLease_Restore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leases.json")
+
+	newYkMan := func() *ykman.YkMan {
+		store, err := ykman.NewFileLeaseStore(path)
+		require.NoError(t, err)
+
+		yk, _ := newSimYkMan(t, 2, ykman.WithLeaseStore(store))
+		return yk
+	}
+
+	prev := newYkMan()
+	long := acquireOne(t, prev, ykman.AcquireReq{ClientIDs: []string{"long"}, TTL: time.Hour})
+	acquireOne(t, prev, ykman.AcquireReq{ClientIDs: []string{"short"}, TTL: time.Hour, MaxLifetime: 100 * time.Millisecond})
+	require.NoError(t, prev.Close())
+
+	time.Sleep(150 * time.Millisecond)
+	next := newYkMan()
+
+	restored, err := next.ForClient("long")
+	require.NoError(t, err)
+	require.Equal(t, long.Serial(), restored.Serial())
+
+	// the lease which lapsed meanwhile is gone, as is its key for the next client
+	_, err = next.ForClient("short")
+	require.ErrorIs(t, err, ykman.ErrNoAssociated)
+	acquireOne(t, next, ykman.AcquireReq{ClientIDs: []string{"next"}})
+}
+
+func TestDevices_UnplugReplug(t *testing.T) {
+	yk, sim := newSimYkMan(t, 1, ykman.WithMissingGrace(time.Hour))
+	dev := sim.Devices()[0]
+
+	held := acquireOne(t, yk, ykman.AcquireReq{ClientIDs: []string{"a"}})
+
+	// a key leaving the bus for a moment keeps its lease
+	dev.Unplug()
+	require.NoError(t, yk.ReloadDevices())
+	require.True(t, held.IsMissing())
+
+	dev.Plug()
+	require.NoError(t, yk.ReloadDevices())
+	require.False(t, held.IsMissing())
+	require.Equal(t, dev.Path(), held.Path())
+
+	owner, err := yk.ForClient("a")
+	require.NoError(t, err)
+	require.Equal(t, held, owner)
+}
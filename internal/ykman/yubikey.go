@@ -1,37 +1,71 @@
 package ykman
 
 import (
+	"context"
 	"fmt"
 	"sync"
 	"time"
 
-	"github.com/buglloc/fidoctl"
+	"github.com/buglloc/yubictld/internal/touchctl"
 )
 
 type Yubikey struct {
-	dev        fidoctl.Device
-	serial     uint32
-	version    string
-	client     string
-	port       int
-	mu         sync.Mutex
-	lastAccess time.Time
+	dev          Device
+	enum         Enumerator
+	serial       uint32
+	version      Version
+	formFactor   FormFactor
+	interfaces   Interface
+	vendor       string
+	model        string
+	client       string
+	group        string
+	touch        TouchPoint
+	touches      map[*touchTask]struct{}
+	mu           sync.Mutex
+	lastAccess   time.Time
+	acquiredAt   time.Time
+	ttl          time.Duration
+	maxLifetime  time.Duration
+	missingSince time.Time
+	failures     int
+	quarantine   Quarantine
+	cleaning     bool
+	probing      bool
 }
 
-func newYubikey(dev fidoctl.Device, discovery Discovery) (*Yubikey, error) {
-	cfg, err := dev.YubiConfig()
+type Quarantine struct {
+	Reason string
+	Since  time.Time
+}
+
+func (q Quarantine) IsZero() bool {
+	return q.Since.IsZero()
+}
+
+func newYubikey(enum Enumerator, dev Device, discovery Discovery) (*Yubikey, error) {
+	info, err := dev.Info()
 	if err != nil {
-		return nil, fmt.Errorf("get Yubikey config: %w", err)
+		return nil, fmt.Errorf("get device info: %w", err)
+	}
+
+	if info.Serial == 0 {
+		return nil, fmt.Errorf("%s: %w", dev, ErrUnidentifiable)
 	}
 
 	y := &Yubikey{
-		dev:     dev,
-		serial:  cfg.Serial(),
-		version: cfg.Version().String(),
+		dev:        dev,
+		enum:       enum,
+		serial:     info.Serial,
+		version:    info.Version,
+		formFactor: info.FormFactor,
+		interfaces: info.Interfaces,
+		vendor:     info.Vendor,
+		model:      info.Model,
 	}
 
 	if discovery != nil {
-		y.port = discovery.Port(y)
+		y.touch = discovery.Locate(y)
 	}
 
 	return y, nil
@@ -44,41 +78,285 @@ func (y *Yubikey) IsFree() bool {
 	return y.client == ""
 }
 
-func (y *Yubikey) Acquire(clientID string) error {
+func (y *Yubikey) Acquire(clientID string, groupID string, ttl time.Duration, maxLifetime time.Duration) error {
 	y.mu.Lock()
 	defer y.mu.Unlock()
 
+	now := time.Now()
 	y.client = clientID
+	y.group = groupID
+	y.lastAccess = now
+	y.acquiredAt = now
+	y.ttl = ttl
+	y.maxLifetime = maxLifetime
+
+	return nil
+}
+
+// Reboot reboots the Yubikey through its device and waits until it is enumerated again.
+// Returns how long the Yubikey was unavailable.
+func (y *Yubikey) Reboot(ctx context.Context, timeout time.Duration) (time.Duration, error) {
+	return y.restart(ctx, func() (Device, error) {
+		dev := y.device()
+		if r, ok := dev.(FallbackRebooter); ok {
+			return r.RebootVia()
+		}
+
+		return dev, dev.Reboot()
+	}, timeout)
+}
+
+func (y *Yubikey) Ping() error {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
 	y.lastAccess = time.Now()
 
 	return nil
 }
 
-func (y *Yubikey) Reboot() error {
+func (y *Yubikey) Lease() Lease {
 	y.mu.Lock()
 	defer y.mu.Unlock()
 
-	if err := y.dev.Reboot(); err != nil {
-		return err
+	return Lease{
+		Serial:      y.serial,
+		ClientID:    y.client,
+		GroupID:     y.group,
+		LastAccess:  y.lastAccess,
+		AcquiredAt:  y.acquiredAt,
+		TTL:         y.ttl,
+		MaxLifetime: y.maxLifetime,
 	}
+}
+
+func (y *Yubikey) LastAccess() time.Time {
+	y.mu.Lock()
+	defer y.mu.Unlock()
 
-	return y.Ping()
+	return y.lastAccess
 }
 
-func (y *Yubikey) Ping() error {
+func (y *Yubikey) IsMissing() bool {
 	y.mu.Lock()
 	defer y.mu.Unlock()
 
-	y.lastAccess = time.Now()
+	return !y.missingSince.IsZero()
+}
+
+func (y *Yubikey) MissingSince() time.Time {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.missingSince
+}
+
+func (y *Yubikey) IsQuarantined() bool {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return !y.quarantine.IsZero()
+}
+
+func (y *Yubikey) Quarantine() Quarantine {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.quarantine
+}
+
+// IsCleaning reports whether the release policies are still being applied to the Yubikey.
+func (y *Yubikey) IsCleaning() bool {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.cleaning
+}
+
+func (y *Yubikey) Failures() int {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.failures
+}
+
+func (y *Yubikey) setCleaning(cleaning bool) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	y.cleaning = cleaning
+}
+
+func (y *Yubikey) isProbing() bool {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.probing
+}
+
+func (y *Yubikey) setProbing(probing bool) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	y.probing = probing
+}
+
+func (y *Yubikey) device() Device {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.dev
+}
+
+// waitReenumerated waits until the Yubikey shows up again after a reboot and takes over its new device handle.
+// The device is accepted only once it has vanished or shows up under a path other than prevPath,
+// so the stale handle of a key that has not gone down yet is never taken for the rebooted one.
+// An empty prevPath accepts the first answering device, which suits in-place resets.
+func (y *Yubikey) waitReenumerated(ctx context.Context, prevPath string) error {
+	ticker := time.NewTicker(200 * time.Millisecond)
+	defer ticker.Stop()
+
+	vanished := prevPath == ""
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		devices, err := y.enum.Enumerate()
+		if err != nil {
+			continue
+		}
+
+		var found Device
+		for _, dev := range devices {
+			info, err := dev.Info()
+			if err == nil && info.Serial == y.serial {
+				found = dev
+				break
+			}
+		}
+
+		switch {
+		case found == nil:
+			vanished = true
+			continue
+		case !vanished && found.Path() == prevPath:
+			continue
+		}
+
+		y.mu.Lock()
+		y.dev = found
+		y.missingSince = time.Time{}
+		y.mu.Unlock()
+		return nil
+	}
+}
+
+func (y *Yubikey) setQuarantine(reason string) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	y.quarantine = Quarantine{
+		Reason: reason,
+		Since:  time.Now(),
+	}
+}
+
+func (y *Yubikey) clearQuarantine() {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	y.quarantine = Quarantine{}
+	y.failures = 0
+}
+
+// recordResult tracks consecutive operation failures and returns their count.
+func (y *Yubikey) recordResult(err error) int {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if err == nil {
+		y.failures = 0
+		return 0
+	}
+
+	y.failures++
+	return y.failures
+}
+
+// probe checks that the Yubikey still answers and is the one we think it is.
+func (y *Yubikey) probe() error {
+	y.mu.Lock()
+	dev := y.dev
+	y.mu.Unlock()
+
+	info, err := dev.Info()
+	if err != nil {
+		return fmt.Errorf("read device info: %w", err)
+	}
+
+	if info.Serial != y.serial {
+		return fmt.Errorf("unexpected serial: %d", info.Serial)
+	}
 
 	return nil
 }
 
+func (y *Yubikey) markMissing() {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	y.missingSince = time.Now()
+}
+
+// attach takes over the device handle of a freshly discovered instance of the same Yubikey.
+func (y *Yubikey) attach(other *Yubikey) {
+	other.mu.Lock()
+	dev, touch := other.dev, other.touch
+	version, formFactor, interfaces := other.version, other.formFactor, other.interfaces
+	vendor, model := other.vendor, other.model
+	other.mu.Unlock()
+
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	y.dev = dev
+	y.touch = touch
+	y.version = version
+	y.formFactor = formFactor
+	y.interfaces = interfaces
+	y.vendor = vendor
+	y.model = model
+	y.missingSince = time.Time{}
+}
+
+func (y *Yubikey) restore(lease Lease) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	y.client = lease.ClientID
+	y.group = lease.GroupID
+	y.lastAccess = lease.LastAccess
+	y.acquiredAt = lease.AcquiredAt
+	y.ttl = lease.TTL
+	y.maxLifetime = lease.MaxLifetime
+}
+
 func (y *Yubikey) Release() error {
 	y.mu.Lock()
 	defer y.mu.Unlock()
 
+	for task := range y.touches {
+		task.cancel()
+	}
+
+	y.touches = nil
 	y.client = ""
+	y.group = ""
+	y.ttl = 0
+	y.maxLifetime = 0
 
 	return nil
 }
@@ -87,15 +365,127 @@ func (y *Yubikey) Serial() uint32 {
 	return y.serial
 }
 
+func (y *Yubikey) Group() string {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.group
+}
+
+func (y *Yubikey) Version() Version {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.version
+}
+
+func (y *Yubikey) FormFactor() FormFactor {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.formFactor
+}
+
+func (y *Yubikey) Interfaces() Interface {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.interfaces
+}
+
+// Vendor returns the authenticator vendor, Yubico for the real Yubikeys.
+func (y *Yubikey) Vendor() string {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.vendor
+}
+
+func (y *Yubikey) Model() string {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.model
+}
+
 func (y *Yubikey) Path() string {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
 	return y.dev.Path()
 }
 
+// Reader returns the PC/SC reader name of the Yubikey, if the device backend knows it.
+func (y *Yubikey) Reader() string {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if r, ok := y.dev.(ReaderDevice); ok {
+		return r.Reader()
+	}
+
+	return ""
+}
+
 func (y *Yubikey) Port() int {
-	return y.port
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.touch.Port
+}
+
+func (y *Yubikey) ToucherID() string {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.touch.ToucherID
+}
+
+// addTouch tracks the touch scheduled under the current lease, so that releasing it cancels the touch.
+func (y *Yubikey) addTouch(task *touchTask) (string, error) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if y.client == "" {
+		return "", fmt.Errorf("%s: %w", y, ErrNotLeased)
+	}
+
+	if y.touch.IsZero() {
+		return "", fmt.Errorf("%s: %w", y, ErrNoTouchPoint)
+	}
+
+	if y.touches == nil {
+		y.touches = make(map[*touchTask]struct{})
+	}
+
+	y.touches[task] = struct{}{}
+	return y.client, nil
+}
+
+func (y *Yubikey) removeTouch(task *touchTask) {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	delete(y.touches, task)
+}
+
+// Touch performs the gesture with the toucher the Yubikey is wired to.
+func (y *Yubikey) Touch(ctx context.Context, g touchctl.Gesture) error {
+	y.mu.Lock()
+	touch := y.touch
+	y.mu.Unlock()
+
+	if touch.IsZero() {
+		return fmt.Errorf("%s: %w", y, ErrNoTouchPoint)
+	}
+
+	return touchctl.Perform(ctx, touch.Toucher, touch.Port, g)
 }
 
 func (y *Yubikey) Location() string {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
 	return y.dev.Location()
 }
 
@@ -0,0 +1,138 @@
+package yubictl
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+)
+
+// HeaderIdempotencyKey identifies a touch request, so its retries are performed only once.
+const HeaderIdempotencyKey = "Idempotency-Key"
+
+func (c *SvcClient) Yubikeys(ctx context.Context) ([]YubikeyStatus, error) {
+	var out YubikeysRsp
+	var serviceErr ServiceError
+	rsp, err := c.httpc.R().
+		SetContext(ctx).
+		SetError(&serviceErr).
+		SetResult(&out).
+		ForceContentType("application/json").
+		Get("/v1/admin/yubikeys")
+
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if !rsp.IsSuccess() {
+		if serviceErr.Code != ServiceErrorCodeNone {
+			return nil, &serviceErr
+		}
+
+		return nil, fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+	}
+
+	return out.Yubikeys, nil
+}
+
+func (c *SvcClient) Quarantine(ctx context.Context, serial uint32, reason string) (*YubikeyStatus, error) {
+	var out YubikeyStatus
+	var serviceErr ServiceError
+	rsp, err := c.httpc.R().
+		SetContext(ctx).
+		SetError(&serviceErr).
+		SetResult(&out).
+		SetBody(QuarantineReq{
+			Serial: serial,
+			Reason: reason,
+		}).
+		ForceContentType("application/json").
+		Post("/v1/admin/quarantine")
+
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if !rsp.IsSuccess() {
+		if serviceErr.Code != ServiceErrorCodeNone {
+			return nil, &serviceErr
+		}
+
+		return nil, fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+	}
+
+	return &out, nil
+}
+
+func (c *SvcClient) Unquarantine(ctx context.Context, serial uint32) (*YubikeyStatus, error) {
+	var out YubikeyStatus
+	var serviceErr ServiceError
+	rsp, err := c.httpc.R().
+		SetContext(ctx).
+		SetError(&serviceErr).
+		SetResult(&out).
+		SetBody(UnquarantineReq{
+			Serial: serial,
+		}).
+		ForceContentType("application/json").
+		Post("/v1/admin/unquarantine")
+
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if !rsp.IsSuccess() {
+		if serviceErr.Code != ServiceErrorCodeNone {
+			return nil, &serviceErr
+		}
+
+		return nil, fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+	}
+
+	return &out, nil
+}
+
+// TouchPort touches the toucher port directly, bypassing leases. Requires the admin token.
+// An empty toucher means the default one. Retries of the request never touch twice.
+func (c *SvcClient) TouchPort(ctx context.Context, toucher string, port int, opts ...TouchOption) error {
+	var touchReq TouchReq
+	for _, opt := range opts {
+		opt(&touchReq)
+	}
+
+	var serviceErr ServiceError
+	rsp, err := c.httpc.R().
+		SetContext(ctx).
+		SetError(&serviceErr).
+		SetHeader(HeaderIdempotencyKey, newIdempotencyKey()).
+		SetBody(PortTouchReq{
+			Toucher:  toucher,
+			Port:     port,
+			Delay:    touchReq.Delay,
+			Duration: touchReq.Duration,
+			Preset:   touchReq.Preset,
+			Steps:    touchReq.Steps,
+		}).
+		ForceContentType("application/json").
+		Post("/v1/admin/touch")
+
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+
+	if !rsp.IsSuccess() {
+		if serviceErr.Code != ServiceErrorCodeNone {
+			return &serviceErr
+		}
+
+		return fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+	}
+
+	return nil
+}
+
+func newIdempotencyKey() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
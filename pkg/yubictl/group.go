@@ -0,0 +1,184 @@
+package yubictl
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/rs/zerolog/log"
+)
+
+type Group struct {
+	id        string
+	yubikeys  []*Yubikey
+	pingTick  time.Duration
+	httpc     *resty.Client
+	ctx       context.Context
+	cancelCtx context.CancelFunc
+	closed    chan struct{}
+	mu        sync.Mutex
+	expiresAt time.Time
+}
+
+func (g *Group) ID() string {
+	return g.id
+}
+
+func (g *Group) Yubikeys() []*Yubikey {
+	return g.yubikeys
+}
+
+// ExpiresAt returns the time the first lease of the group lapses unless it is renewed before.
+func (g *Group) ExpiresAt() time.Time {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.expiresAt
+}
+
+func (g *Group) Renew(ctx context.Context) (*RenewRsp, error) {
+	var out RenewRsp
+	var serviceErr ServiceError
+	rsp, err := g.httpc.R().
+		SetContext(ctx).
+		SetError(&serviceErr).
+		SetResult(&out).
+		SetBody(RenewReq{
+			ID: g.id,
+		}).
+		ForceContentType("application/json").
+		Post("/v1/renew")
+
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if !rsp.IsSuccess() {
+		if serviceErr.Code != ServiceErrorCodeNone {
+			return nil, &serviceErr
+		}
+
+		return nil, fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+	}
+
+	for _, status := range out.Yubikeys {
+		for _, yk := range g.yubikeys {
+			yk.updateStatus(status)
+		}
+	}
+
+	g.mu.Lock()
+	g.expiresAt = out.ExpiresAt
+	if out.PingInterval > 0 {
+		g.pingTick = out.PingInterval
+	}
+	g.mu.Unlock()
+
+	return &out, nil
+}
+
+func (g *Group) Ping(ctx context.Context) error {
+	var out PingRsp
+	var serviceErr ServiceError
+	rsp, err := g.httpc.R().
+		SetContext(ctx).
+		SetError(&serviceErr).
+		SetResult(&out).
+		SetBody(PingReq{
+			ID: g.id,
+		}).
+		ForceContentType("application/json").
+		Post("/v1/ping")
+
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+
+	if !rsp.IsSuccess() {
+		if serviceErr.Code != ServiceErrorCodeNone {
+			return &serviceErr
+		}
+
+		return fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+	}
+
+	for _, status := range out.Yubikeys {
+		for _, yk := range g.yubikeys {
+			yk.updateStatus(status)
+		}
+	}
+
+	return nil
+}
+
+func (g *Group) Release(ctx context.Context) error {
+	g.cancelCtx()
+	for _, yk := range g.yubikeys {
+		yk.cancelCtx()
+	}
+
+	var serviceErr ServiceError
+	rsp, err := g.httpc.R().
+		SetContext(ctx).
+		SetError(&serviceErr).
+		SetBody(ReleaseReq{
+			ID: g.id,
+		}).
+		ForceContentType("application/json").
+		Post("/v1/release")
+
+	if err != nil {
+		return fmt.Errorf("request failed: %w", err)
+	}
+
+	if !rsp.IsSuccess() {
+		if serviceErr.Code != ServiceErrorCodeNone {
+			return &serviceErr
+		}
+
+		return fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+	}
+
+	return nil
+}
+
+func (g *Group) Close(ctx context.Context) error {
+	return g.Release(ctx)
+}
+
+func (g *Group) pingLoop() {
+	defer close(g.closed)
+
+	tick := g.pingInterval()
+	ticker := time.NewTicker(tick)
+	for {
+		select {
+		case <-g.ctx.Done():
+			ticker.Stop()
+			return
+		case <-ticker.C:
+			if _, err := g.Renew(g.ctx); err != nil {
+				log.Error().
+					Err(err).
+					Str("group_id", g.id).
+					Time("expires_at", g.ExpiresAt()).
+					Msg("yubikeys group lease renew failed")
+				continue
+			}
+
+			if newTick := g.pingInterval(); newTick != tick {
+				tick = newTick
+				ticker.Reset(tick)
+			}
+		}
+	}
+}
+
+func (g *Group) pingInterval() time.Duration {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.pingTick
+}
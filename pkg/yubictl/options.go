@@ -1,19 +1,94 @@
 package yubictl
 
-import "time"
+import (
+	"net/http"
+	"time"
+)
 
 const (
 	DefaultPingInterval = 5 * time.Second
+	DefaultAcquireWait  = 5 * time.Minute
 )
 
 type Option func(*SvcClient)
 
+// WithPingInterval sets the lease keepalive interval used when the server doesn't recommend one.
 func WithPingInterval(d time.Duration) Option {
 	return func(c *SvcClient) {
 		c.pingInterval = d
 	}
 }
 
+func WithAcquireWait(d time.Duration) Option {
+	return func(c *SvcClient) {
+		c.acquireWait = d
+	}
+}
+
+type AcquireOption func(r *AcquireReq)
+
+func AcquireWithWait(d time.Duration) AcquireOption {
+	return func(r *AcquireReq) {
+		r.Wait = d
+	}
+}
+
+// AcquireWithTTL requests the idle TTL of the lease: it expires when not pinged for that long.
+func AcquireWithTTL(ttl time.Duration) AcquireOption {
+	return func(r *AcquireReq) {
+		r.TTL = ttl
+	}
+}
+
+// AcquireWithMaxLifetime requests the absolute lifetime of the lease, regardless of pings.
+func AcquireWithMaxLifetime(lifetime time.Duration) AcquireOption {
+	return func(r *AcquireReq) {
+		r.MaxLifetime = lifetime
+	}
+}
+
+func AcquireWithSerial(serial uint32) AcquireOption {
+	return func(r *AcquireReq) {
+		r.Serial = serial
+	}
+}
+
+func AcquireWithMinVersion(version string) AcquireOption {
+	return func(r *AcquireReq) {
+		r.MinVersion = version
+	}
+}
+
+func AcquireWithMaxVersion(version string) AcquireOption {
+	return func(r *AcquireReq) {
+		r.MaxVersion = version
+	}
+}
+
+func AcquireWithFormFactor(formFactor string) AcquireOption {
+	return func(r *AcquireReq) {
+		r.FormFactor = formFactor
+	}
+}
+
+func AcquireWithInterfaces(interfaces ...string) AcquireOption {
+	return func(r *AcquireReq) {
+		r.Interfaces = interfaces
+	}
+}
+
+func AcquireWithLocation(location string) AcquireOption {
+	return func(r *AcquireReq) {
+		r.Location = location
+	}
+}
+
+func AcquireWithTouch() AcquireOption {
+	return func(r *AcquireReq) {
+		r.Touchable = true
+	}
+}
+
 type TouchOption func(r *TouchReq)
 
 func TouchWithDuration(d time.Duration) TouchOption {
@@ -27,3 +102,79 @@ func TouchWithDelay(d time.Duration) TouchOption {
 		r.Delay = d
 	}
 }
+
+// TouchWithSteps performs the press/release steps back to back.
+func TouchWithSteps(steps ...TouchStep) TouchOption {
+	return func(r *TouchReq) {
+		r.Steps = append(r.Steps, steps...)
+	}
+}
+
+func TouchWithPreset(preset TouchPreset) TouchOption {
+	return func(r *TouchReq) {
+		r.Preset = preset
+	}
+}
+
+// TouchShort is a brief tap, e.g. to confirm a FIDO operation.
+func TouchShort() TouchOption {
+	return TouchWithPreset(TouchPresetShort)
+}
+
+// TouchLongOTPSlot2 holds long enough to trigger the OTP slot 2.
+func TouchLongOTPSlot2() TouchOption {
+	return TouchWithPreset(TouchPresetLongOTPSlot2)
+}
+
+// TouchDouble is two short taps in a row.
+func TouchDouble() TouchOption {
+	return TouchWithPreset(TouchPresetDouble)
+}
+
+type AcquireGroupOption func(r *AcquireGroupReq)
+
+func GroupWithSelector(opts ...AcquireOption) AcquireGroupOption {
+	return func(r *AcquireGroupReq) {
+		for _, opt := range opts {
+			opt(&r.AcquireReq)
+		}
+	}
+}
+
+func GroupWithSameHub() AcquireGroupOption {
+	return func(r *AcquireGroupReq) {
+		r.SameHub = true
+	}
+}
+
+func GroupWithDistinctVersions() AcquireGroupOption {
+	return func(r *AcquireGroupReq) {
+		r.DistinctVersions = true
+	}
+}
+
+// WithTransport overrides the HTTP transport, e.g. to talk to the daemon over a unix socket.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *SvcClient) {
+		c.httpc.SetTransport(rt)
+	}
+}
+
+// WithAdminToken sets the bearer token to authenticate admin requests.
+func WithAdminToken(token string) Option {
+	return func(c *SvcClient) {
+		c.httpc.SetAuthToken(token)
+	}
+}
+
+func WithTimeout(d time.Duration) Option {
+	return func(c *SvcClient) {
+		c.httpc.SetTimeout(d)
+	}
+}
+
+func WithRetryCount(n int) Option {
+	return func(c *SvcClient) {
+		c.httpc.SetRetryCount(n)
+	}
+}
@@ -8,6 +8,11 @@ const (
 	ServiceErrorCodeNone ServiceErrorCode = iota
 	ServiceErrorInternalError
 	ServiceErrorNoFreeYubikey
+	ServiceErrorNoMatchingYubikey
+	ServiceErrorUnknownYubikey
+	ServiceErrorRebootTimeout
+	ServiceErrorNotSupported
+	ServiceErrorUnknownTouch
 )
 
 type ServiceError struct {
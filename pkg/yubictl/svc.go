@@ -2,6 +2,7 @@ package yubictl
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -9,14 +10,20 @@ import (
 	"github.com/go-resty/resty/v2"
 )
 
+// acquireWaitMargin is kept between the end of the server side wait and the caller deadline,
+// so a Yubikey granted at the last moment still reaches the caller in time.
+const acquireWaitMargin = 2 * time.Second
+
 type SvcClient struct {
 	pingInterval time.Duration
+	acquireWait  time.Duration
 	httpc        *resty.Client
 }
 
 func NewSvcClient(upstream string, opts ...Option) *SvcClient {
 	c := &SvcClient{
 		pingInterval: DefaultPingInterval,
+		acquireWait:  DefaultAcquireWait,
 		httpc: resty.New().
 			SetJSONEscapeHTML(false).
 			SetHeader("Content-Type", "application/json").
@@ -36,16 +43,21 @@ func NewSvcClient(upstream string, opts ...Option) *SvcClient {
 	return c
 }
 
-func (c *SvcClient) Acquire(ctx context.Context) (*Yubikey, error) {
+func (c *SvcClient) Acquire(ctx context.Context, opts ...AcquireOption) (*Yubikey, error) {
+	req := &AcquireReq{
+		Wait: c.acquireWait,
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Wait = max(time.Until(deadline)-acquireWaitMargin, 0)
+	}
+
+	for _, opt := range opts {
+		opt(req)
+	}
+
 	var out AcquireRsp
 	var serviceErr ServiceError
-	rsp, err := c.httpc.R().
-		SetContext(ctx).
-		SetError(&serviceErr).
-		SetResult(&out).
-		ForceContentType("application/json").
-		Post("/v1/acquire")
-
+	rsp, err := c.acquireCall(ctx, "/v1/acquire", req, &out, &serviceErr)
 	if err != nil {
 		return nil, fmt.Errorf("request failed: %w", err)
 	}
@@ -62,17 +74,160 @@ func (c *SvcClient) Acquire(ctx context.Context) (*Yubikey, error) {
 		return nil, fmt.Errorf("server returns unexpected response: %s", rsp.String())
 	}
 
+	if err := ctx.Err(); err != nil {
+		c.releaseLate(out.ID)
+		return nil, err
+	}
+
 	yCtx, yCancel := context.WithCancel(context.Background())
 	yk := &Yubikey{
+		id:          out.ID,
+		serial:      out.Serial,
+		ttl:         out.TTL,
+		maxLifetime: out.MaxLifetime,
+		expiresAt:   out.ExpiresAt,
+		info:        out.Info,
+		httpc:       c.httpc,
+		pingTick:    c.effectivePingInterval(out.PingInterval),
+		ctx:         yCtx,
+		cancelCtx:   yCancel,
+		closed:      make(chan struct{}),
+	}
+	go yk.pingLoop()
+
+	return yk, nil
+}
+
+func (c *SvcClient) AcquireGroup(ctx context.Context, count int, opts ...AcquireGroupOption) (*Group, error) {
+	req := &AcquireGroupReq{
+		AcquireReq: AcquireReq{
+			Wait: c.acquireWait,
+		},
+		Count: count,
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		req.Wait = max(time.Until(deadline)-acquireWaitMargin, 0)
+	}
+
+	for _, opt := range opts {
+		opt(req)
+	}
+
+	var out AcquireGroupRsp
+	var serviceErr ServiceError
+	rsp, err := c.acquireCall(ctx, "/v1/acquire-group", req, &out, &serviceErr)
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if !rsp.IsSuccess() {
+		if serviceErr.Code != ServiceErrorCodeNone {
+			return nil, &serviceErr
+		}
+
+		return nil, fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+	}
+
+	if out.ID == "" || len(out.Yubikeys) != count {
+		return nil, fmt.Errorf("server returns unexpected response: %s", rsp.String())
+	}
+
+	if err := ctx.Err(); err != nil {
+		c.releaseLate(out.ID)
+		return nil, err
+	}
+
+	gCtx, gCancel := context.WithCancel(context.Background())
+	g := &Group{
 		id:        out.ID,
-		serial:    out.Serial,
+		yubikeys:  make([]*Yubikey, len(out.Yubikeys)),
 		httpc:     c.httpc,
 		pingTick:  c.pingInterval,
-		ctx:       yCtx,
-		cancelCtx: yCancel,
+		ctx:       gCtx,
+		cancelCtx: gCancel,
 		closed:    make(chan struct{}),
 	}
-	go yk.pingLoop()
 
-	return yk, nil
+	for i, member := range out.Yubikeys {
+		if member.ID == "" || member.Serial == 0 {
+			gCancel()
+			return nil, fmt.Errorf("server returns unexpected response: %s", rsp.String())
+		}
+
+		// group members share the group's lease, so they don't ping on their own
+		yCtx, yCancel := context.WithCancel(gCtx)
+		if g.expiresAt.IsZero() || member.ExpiresAt.Before(g.expiresAt) {
+			g.expiresAt = member.ExpiresAt
+		}
+
+		if interval := c.effectivePingInterval(member.PingInterval); interval < g.pingTick {
+			g.pingTick = interval
+		}
+
+		g.yubikeys[i] = &Yubikey{
+			id:          member.ID,
+			serial:      member.Serial,
+			ttl:         member.TTL,
+			maxLifetime: member.MaxLifetime,
+			expiresAt:   member.ExpiresAt,
+			info:        member.Info,
+			httpc:       c.httpc,
+			pingTick:    c.effectivePingInterval(member.PingInterval),
+			ctx:         yCtx,
+			cancelCtx:   yCancel,
+			closed:      make(chan struct{}),
+		}
+	}
+	go g.pingLoop()
+
+	return g, nil
+}
+
+// acquireCall performs the acquire request. Once the ctx deadline is hit the request is given
+// acquireWaitMargin more, as the server may have granted the Yubikeys right before it.
+func (c *SvcClient) acquireCall(ctx context.Context, path string, req any, out any, serviceErr *ServiceError) (*resty.Response, error) {
+	reqCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer cancel()
+
+	stop := context.AfterFunc(ctx, func() {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			time.AfterFunc(acquireWaitMargin, cancel)
+			return
+		}
+
+		cancel()
+	})
+	defer stop()
+
+	return c.httpc.R().
+		SetContext(reqCtx).
+		SetError(serviceErr).
+		SetBody(req).
+		SetResult(out).
+		ForceContentType("application/json").
+		Post(path)
+}
+
+// releaseLate releases the lease granted to the caller which is not waiting anymore,
+// so it doesn't hold the Yubikeys until the lease TTL.
+func (c *SvcClient) releaseLate(id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, _ = c.httpc.R().
+		SetContext(ctx).
+		SetBody(ReleaseReq{
+			ID: id,
+		}).
+		Post("/v1/release")
+}
+
+// effectivePingInterval prefers the keepalive interval recommended by the server
+// over the configured one.
+func (c *SvcClient) effectivePingInterval(recommended time.Duration) time.Duration {
+	if recommended > 0 {
+		return recommended
+	}
+
+	return c.pingInterval
 }
@@ -0,0 +1,98 @@
+package yubictl_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/buglloc/yubictld/pkg/yubictl"
+)
+
+// slowServer grants a Yubikey only after the delay.
+type slowServer struct {
+	delay    time.Duration
+	mu       sync.Mutex
+	waits    []time.Duration
+	released []string
+}
+
+func (s *slowServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/v1/acquire":
+		var req yubictl.AcquireReq
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		s.mu.Lock()
+		s.waits = append(s.waits, req.Wait)
+		s.mu.Unlock()
+
+		time.Sleep(s.delay)
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(yubictl.AcquireRsp{
+			ID:        "lease",
+			Serial:    1,
+			TTL:       time.Minute,
+			ExpiresAt: time.Now().Add(time.Minute),
+		})
+
+	case "/v1/release":
+		var req yubictl.ReleaseReq
+		_ = json.NewDecoder(r.Body).Decode(&req)
+
+		s.mu.Lock()
+		s.released = append(s.released, req.ID)
+		s.mu.Unlock()
+	}
+}
+
+func (s *slowServer) Waits() []time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]time.Duration(nil), s.waits...)
+}
+
+func (s *slowServer) Released() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.released...)
+}
+
+func TestAcquire_WaitLeavesMargin(t *testing.T) {
+	upstream := &slowServer{}
+	srv := httptest.NewServer(upstream)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	yk, err := yubictl.NewSvcClient(srv.URL).Acquire(ctx)
+	require.NoError(t, err)
+	defer func() { _ = yk.Close(ctx) }()
+
+	waits := upstream.Waits()
+	require.Len(t, waits, 1)
+	require.Greater(t, waits[0], 7*time.Second)
+	require.LessOrEqual(t, waits[0], 8*time.Second)
+}
+
+func TestAcquire_LateGrantIsReleased(t *testing.T) {
+	upstream := &slowServer{delay: 500 * time.Millisecond}
+	srv := httptest.NewServer(upstream)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err := yubictl.NewSvcClient(srv.URL, yubictl.WithRetryCount(0)).Acquire(ctx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, []time.Duration{0}, upstream.Waits())
+	require.Equal(t, []string{"lease"}, upstream.Released())
+}
@@ -2,25 +2,220 @@ package yubictl
 
 import "time"
 
+type AcquireReq struct {
+	Serial      uint32        `json:"serial,omitempty"`
+	MinVersion  string        `json:"min_version,omitempty"`
+	MaxVersion  string        `json:"max_version,omitempty"`
+	FormFactor  string        `json:"form_factor,omitempty"`
+	Interfaces  []string      `json:"interfaces,omitempty"`
+	Location    string        `json:"location,omitempty"`
+	Touchable   bool          `json:"touchable,omitempty"`
+	Wait        time.Duration `json:"wait,omitempty"`
+	TTL         time.Duration `json:"ttl,omitempty"`
+	MaxLifetime time.Duration `json:"max_lifetime,omitempty"`
+}
+
 type AcquireRsp struct {
-	ID     string `json:"id"`
-	Serial uint32 `json:"serial"`
+	ID           string        `json:"id"`
+	Serial       uint32        `json:"serial"`
+	TTL          time.Duration `json:"ttl"`
+	MaxLifetime  time.Duration `json:"max_lifetime,omitempty"`
+	ExpiresAt    time.Time     `json:"expires_at"`
+	PingInterval time.Duration `json:"ping_interval"`
+	Info         YubikeyInfo   `json:"info"`
+}
+
+// YubikeyInfo describes the leased Yubikey and how to reach it locally.
+type YubikeyInfo struct {
+	Serial     uint32   `json:"serial"`
+	Vendor     string   `json:"vendor"`
+	Model      string   `json:"model"`
+	Version    string   `json:"version"`
+	FormFactor string   `json:"form_factor"`
+	Interfaces []string `json:"interfaces"`
+	Path       string   `json:"path"`
+	Reader     string   `json:"reader,omitempty"`
+	Location   string   `json:"location"`
+	Toucher    string   `json:"toucher,omitempty"`
+	Port       int      `json:"port"`
+}
+
+type AcquireGroupReq struct {
+	AcquireReq
+	Count            int  `json:"count"`
+	SameHub          bool `json:"same_hub,omitempty"`
+	DistinctVersions bool `json:"distinct_versions,omitempty"`
+}
+
+type AcquireGroupRsp struct {
+	ID       string       `json:"id"`
+	Yubikeys []AcquireRsp `json:"yubikeys"`
 }
 
 type PingReq struct {
 	ID string `json:"id"`
 }
 
+type PingRsp struct {
+	Yubikeys []LeaseStatus `json:"yubikeys"`
+}
+
+type LeaseStatus struct {
+	ID           string    `json:"id"`
+	Serial       uint32    `json:"serial"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	Missing      bool      `json:"missing,omitempty"`
+	MissingSince time.Time `json:"missing_since,omitzero"`
+}
+
+type RenewReq struct {
+	ID string `json:"id"`
+}
+
+type RenewRsp struct {
+	ExpiresAt         time.Time     `json:"expires_at"`
+	RemainingLifetime time.Duration `json:"remaining_lifetime,omitempty"`
+	PingInterval      time.Duration `json:"ping_interval"`
+	Yubikeys          []LeaseStatus `json:"yubikeys"`
+}
+
+const (
+	RebootStrategyFIDO        RebootStrategy = "fido"
+	RebootStrategyUSBReset    RebootStrategy = "usb-reset"
+	RebootStrategyDeauthorize RebootStrategy = "deauthorize"
+)
+
+type RebootStrategy string
+
 type RebootReq struct {
+	ID       string         `json:"id"`
+	Strategy RebootStrategy `json:"strategy,omitempty"`
+}
+
+type RebootRsp struct {
+	Serial   uint32        `json:"serial"`
+	Path     string        `json:"path"`
+	Downtime time.Duration `json:"downtime"`
+}
+
+type PowerCycleReq struct {
 	ID string `json:"id"`
 }
 
+type PowerCycleRsp struct {
+	Serial   uint32        `json:"serial"`
+	Path     string        `json:"path"`
+	Downtime time.Duration `json:"downtime"`
+}
+
+type PortTouchReq struct {
+	Toucher  string        `json:"toucher,omitempty"`
+	Port     int           `json:"port"`
+	Delay    time.Duration `json:"delay"`
+	Duration time.Duration `json:"duration"`
+	Preset   TouchPreset   `json:"preset,omitempty"`
+	Steps    []TouchStep   `json:"steps,omitempty"`
+}
+
+const (
+	TouchPresetShort        TouchPreset = "short"
+	TouchPresetLongOTPSlot2 TouchPreset = "long-otp-slot2"
+	TouchPresetDouble       TouchPreset = "double"
+)
+
+type TouchPreset string
+
+// TouchStep is a single press: released for Delay since the previous step, then held for Duration.
+type TouchStep struct {
+	Delay    time.Duration `json:"delay"`
+	Duration time.Duration `json:"duration"`
+}
+
+// TouchReq touches with either a preset, a list of steps or a single press of Delay and Duration.
 type TouchReq struct {
 	ID       string        `json:"id"`
 	Delay    time.Duration `json:"delay"`
 	Duration time.Duration `json:"duration"`
+	Preset   TouchPreset   `json:"preset,omitempty"`
+	Steps    []TouchStep   `json:"steps,omitempty"`
+}
+
+const (
+	TouchStateScheduled TouchState = "scheduled"
+	TouchStateActuating TouchState = "actuating"
+	TouchStateDone      TouchState = "done"
+	TouchStateFailed    TouchState = "failed"
+	TouchStateCanceled  TouchState = "canceled"
+)
+
+type TouchState string
+
+func (s TouchState) IsFinal() bool {
+	return s == TouchStateDone || s == TouchStateFailed || s == TouchStateCanceled
+}
+
+type TouchStatus struct {
+	TouchID     string     `json:"touch_id"`
+	Serial      uint32     `json:"serial"`
+	State       TouchState `json:"state"`
+	Error       string     `json:"error,omitempty"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	StartedAt   time.Time  `json:"started_at,omitzero"`
+	FinishedAt  time.Time  `json:"finished_at,omitzero"`
+}
+
+type TouchStatusReq struct {
+	ID      string `json:"id"`
+	TouchID string `json:"touch_id"`
+	// Wait blocks until the touch is finished, up to a server side limit
+	Wait bool `json:"wait,omitempty"`
+}
+
+type TouchCancelReq struct {
+	ID      string `json:"id"`
+	TouchID string `json:"touch_id"`
 }
 
 type ReleaseReq struct {
 	ID string `json:"id"`
 }
+
+type YubikeysRsp struct {
+	Yubikeys []YubikeyStatus `json:"yubikeys"`
+}
+
+type YubikeyStatus struct {
+	Serial           uint32    `json:"serial"`
+	Vendor           string    `json:"vendor"`
+	Model            string    `json:"model"`
+	Version          string    `json:"version"`
+	Path             string    `json:"path"`
+	Location         string    `json:"location"`
+	Toucher          string    `json:"toucher,omitempty"`
+	Port             int       `json:"port"`
+	Leased           bool      `json:"leased"`
+	ExpiresAt        time.Time `json:"expires_at,omitzero"`
+	MissingSince     time.Time `json:"missing_since,omitzero"`
+	Cleaning         bool      `json:"cleaning"`
+	Failures         int       `json:"failures"`
+	Quarantined      bool      `json:"quarantined"`
+	QuarantineReason string    `json:"quarantine_reason,omitempty"`
+	QuarantinedAt    time.Time `json:"quarantined_at,omitzero"`
+}
+
+type QuarantineReq struct {
+	Serial uint32 `json:"serial"`
+	Reason string `json:"reason"`
+}
+
+type UnquarantineReq struct {
+	Serial uint32 `json:"serial"`
+}
+
+type InfoReq struct {
+	ID string `json:"id"`
+}
+
+type InfoRsp struct {
+	Yubikeys []YubikeyInfo `json:"yubikeys"`
+}
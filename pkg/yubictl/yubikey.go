@@ -3,6 +3,7 @@ package yubictl
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/go-resty/resty/v2"
@@ -10,13 +11,19 @@ import (
 )
 
 type Yubikey struct {
-	id        string
-	serial    uint32
-	pingTick  time.Duration
-	httpc     *resty.Client
-	ctx       context.Context
-	cancelCtx context.CancelFunc
-	closed    chan struct{}
+	id           string
+	serial       uint32
+	ttl          time.Duration
+	maxLifetime  time.Duration
+	pingTick     time.Duration
+	httpc        *resty.Client
+	ctx          context.Context
+	cancelCtx    context.CancelFunc
+	closed       chan struct{}
+	mu           sync.Mutex
+	expiresAt    time.Time
+	missingSince time.Time
+	info         YubikeyInfo
 }
 
 func (y *Yubikey) ID() string {
@@ -27,7 +34,121 @@ func (y *Yubikey) Serial() uint32 {
 	return y.serial
 }
 
+// Info returns the Yubikey info as reported by the server at acquire or at the last FetchInfo call.
+func (y *Yubikey) Info() YubikeyInfo {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.info
+}
+
+// FetchInfo requests the up-to-date Yubikey info, e.g. to learn its new hidraw path after a reboot.
+func (y *Yubikey) FetchInfo(ctx context.Context) (YubikeyInfo, error) {
+	var out InfoRsp
+	var serviceErr ServiceError
+	rsp, err := y.httpc.R().
+		SetContext(ctx).
+		SetError(&serviceErr).
+		SetResult(&out).
+		SetBody(InfoReq{
+			ID: y.id,
+		}).
+		ForceContentType("application/json").
+		Post("/v1/info")
+
+	if err != nil {
+		return YubikeyInfo{}, fmt.Errorf("request failed: %w", err)
+	}
+
+	if !rsp.IsSuccess() {
+		if serviceErr.Code != ServiceErrorCodeNone {
+			return YubikeyInfo{}, &serviceErr
+		}
+
+		return YubikeyInfo{}, fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+	}
+
+	for _, info := range out.Yubikeys {
+		if info.Serial != y.serial {
+			continue
+		}
+
+		y.mu.Lock()
+		y.info = info
+		y.mu.Unlock()
+		return info, nil
+	}
+
+	return YubikeyInfo{}, fmt.Errorf("server returns unexpected response: %s", rsp.String())
+}
+
+// TTL returns the effective idle TTL of the lease granted by the server.
+func (y *Yubikey) TTL() time.Duration {
+	return y.ttl
+}
+
+// MaxLifetime returns the effective absolute lifetime of the lease, zero means unlimited.
+func (y *Yubikey) MaxLifetime() time.Duration {
+	return y.maxLifetime
+}
+
+// ExpiresAt returns the time the lease lapses unless it is renewed before,
+// as reported by the server at the last renewal.
+func (y *Yubikey) ExpiresAt() time.Time {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.expiresAt
+}
+
+// IsMissing reports whether the server lost the Yubikey at the last ping.
+// The lease is kept for a grace period and reattached once the key is back.
+func (y *Yubikey) IsMissing() bool {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return !y.missingSince.IsZero()
+}
+
+func (y *Yubikey) MissingSince() time.Time {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.missingSince
+}
+
+// Touch touches the Yubikey and blocks until the touch is finished.
+// The touch is canceled if ctx is done before that.
 func (y *Yubikey) Touch(ctx context.Context, opts ...TouchOption) error {
+	scheduled, err := y.ScheduleTouch(ctx, opts...)
+	if err != nil {
+		return err
+	}
+
+	touchID := scheduled.TouchID
+	status, err := y.WaitTouch(ctx, touchID)
+	if err != nil {
+		// don't leave a stray touch behind
+		cancelCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+
+		_, _ = y.CancelTouch(cancelCtx, touchID)
+		return err
+	}
+
+	switch status.State {
+	case TouchStateDone:
+		return nil
+	case TouchStateCanceled:
+		return fmt.Errorf("touch %s was canceled", touchID)
+	default:
+		return fmt.Errorf("touch %s failed: %s", touchID, status.Error)
+	}
+}
+
+// ScheduleTouch schedules the touch and returns right away.
+// Pending touches are canceled once the Yubikey is released.
+func (y *Yubikey) ScheduleTouch(ctx context.Context, opts ...TouchOption) (*TouchStatus, error) {
 	req := &TouchReq{
 		ID: y.id,
 	}
@@ -36,60 +157,152 @@ func (y *Yubikey) Touch(ctx context.Context, opts ...TouchOption) error {
 		opt(req)
 	}
 
+	// the touch is scheduled once, whichever of the retried requests gets through
+	return y.touchCall(y.httpc.R().SetContext(ctx).SetHeader(HeaderIdempotencyKey, newIdempotencyKey()), "/v1/touch", req)
+}
+
+func (y *Yubikey) TouchStatus(ctx context.Context, touchID string) (*TouchStatus, error) {
+	return y.touchCall(y.httpc.R().SetContext(ctx), "/v1/touch/status", TouchStatusReq{
+		ID:      y.id,
+		TouchID: touchID,
+	})
+}
+
+// WaitTouch blocks until the touch is finished or ctx is done.
+func (y *Yubikey) WaitTouch(ctx context.Context, touchID string) (*TouchStatus, error) {
+	for {
+		status, err := y.touchCall(y.httpc.R().SetContext(ctx), "/v1/touch/status", TouchStatusReq{
+			ID:      y.id,
+			TouchID: touchID,
+			Wait:    true,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if status.State.IsFinal() {
+			return status, nil
+		}
+	}
+}
+
+// CancelTouch cancels the touch and blocks until the toucher is released.
+// A press in progress is released early if the toucher supports it, no further presses are made.
+func (y *Yubikey) CancelTouch(ctx context.Context, touchID string) (*TouchStatus, error) {
+	return y.touchCall(y.httpc.R().SetContext(ctx), "/v1/touch/cancel", TouchCancelReq{
+		ID:      y.id,
+		TouchID: touchID,
+	})
+}
+
+func (y *Yubikey) touchCall(r *resty.Request, path string, req any) (*TouchStatus, error) {
+	var out TouchStatus
 	var serviceErr ServiceError
-	rsp, err := y.httpc.R().
-		SetContext(ctx).
+	rsp, err := r.
 		SetError(&serviceErr).
+		SetResult(&out).
 		SetBody(req).
 		ForceContentType("application/json").
-		Post("/v1/touch")
+		Post(path)
 
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
 	if !rsp.IsSuccess() {
 		if serviceErr.Code != ServiceErrorCodeNone {
-			return &serviceErr
+			return nil, &serviceErr
 		}
 
-		return fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+		return nil, fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
 	}
 
-	return nil
+	return &out, nil
+}
+
+// Reboot reboots the Yubikey and blocks until the server sees it back.
+func (y *Yubikey) Reboot(ctx context.Context) (*RebootRsp, error) {
+	return y.RebootWithStrategy(ctx, RebootStrategyFIDO)
 }
 
-func (y *Yubikey) Reboot(ctx context.Context) error {
+// RebootWithStrategy is like Reboot, but lets choose how the Yubikey is rebooted.
+func (y *Yubikey) RebootWithStrategy(ctx context.Context, strategy RebootStrategy) (*RebootRsp, error) {
+	var out RebootRsp
 	var serviceErr ServiceError
 	rsp, err := y.httpc.R().
 		SetContext(ctx).
 		SetError(&serviceErr).
+		SetResult(&out).
 		SetBody(RebootReq{
-			ID: y.id,
+			ID:       y.id,
+			Strategy: strategy,
 		}).
 		ForceContentType("application/json").
 		Post("/v1/reboot")
 
 	if err != nil {
-		return fmt.Errorf("request failed: %w", err)
+		return nil, fmt.Errorf("request failed: %w", err)
 	}
 
 	if !rsp.IsSuccess() {
 		if serviceErr.Code != ServiceErrorCodeNone {
-			return &serviceErr
+			return nil, &serviceErr
 		}
 
-		return fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+		return nil, fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
 	}
 
-	return nil
+	y.mu.Lock()
+	if out.Serial == y.info.Serial {
+		y.info.Path = out.Path
+	}
+	y.mu.Unlock()
+
+	return &out, nil
+}
+
+// PowerCycle cuts the Yubikey hub port power and blocks until the server sees it back.
+func (y *Yubikey) PowerCycle(ctx context.Context) (*PowerCycleRsp, error) {
+	var out PowerCycleRsp
+	var serviceErr ServiceError
+	rsp, err := y.httpc.R().
+		SetContext(ctx).
+		SetError(&serviceErr).
+		SetResult(&out).
+		SetBody(PowerCycleReq{
+			ID: y.id,
+		}).
+		ForceContentType("application/json").
+		Post("/v1/power-cycle")
+
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if !rsp.IsSuccess() {
+		if serviceErr.Code != ServiceErrorCodeNone {
+			return nil, &serviceErr
+		}
+
+		return nil, fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+	}
+
+	y.mu.Lock()
+	if out.Serial == y.info.Serial {
+		y.info.Path = out.Path
+	}
+	y.mu.Unlock()
+
+	return &out, nil
 }
 
 func (y *Yubikey) Ping(ctx context.Context) error {
+	var out PingRsp
 	var serviceErr ServiceError
 	rsp, err := y.httpc.R().
 		SetContext(ctx).
 		SetError(&serviceErr).
+		SetResult(&out).
 		SetBody(PingReq{
 			ID: y.id,
 		}).
@@ -108,9 +321,51 @@ func (y *Yubikey) Ping(ctx context.Context) error {
 		return fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
 	}
 
+	for _, status := range out.Yubikeys {
+		y.updateStatus(status)
+	}
+
 	return nil
 }
 
+func (y *Yubikey) Renew(ctx context.Context) (*RenewRsp, error) {
+	var out RenewRsp
+	var serviceErr ServiceError
+	rsp, err := y.httpc.R().
+		SetContext(ctx).
+		SetError(&serviceErr).
+		SetResult(&out).
+		SetBody(RenewReq{
+			ID: y.id,
+		}).
+		ForceContentType("application/json").
+		Post("/v1/renew")
+
+	if err != nil {
+		return nil, fmt.Errorf("request failed: %w", err)
+	}
+
+	if !rsp.IsSuccess() {
+		if serviceErr.Code != ServiceErrorCodeNone {
+			return nil, &serviceErr
+		}
+
+		return nil, fmt.Errorf("request failed: non-200 status code: %s", rsp.Status())
+	}
+
+	for _, status := range out.Yubikeys {
+		y.updateStatus(status)
+	}
+
+	y.mu.Lock()
+	if out.PingInterval > 0 {
+		y.pingTick = out.PingInterval
+	}
+	y.mu.Unlock()
+
+	return &out, nil
+}
+
 func (y *Yubikey) Release(ctx context.Context) error {
 	y.cancelCtx()
 
@@ -146,20 +401,61 @@ func (y *Yubikey) Close(ctx context.Context) error {
 func (y *Yubikey) pingLoop() {
 	defer close(y.closed)
 
-	ticker := time.NewTicker(y.pingTick)
+	tick := y.pingInterval()
+	ticker := time.NewTicker(tick)
 	for {
 		select {
 		case <-y.ctx.Done():
 			ticker.Stop()
 			return
 		case <-ticker.C:
-			if err := y.Ping(y.ctx); err != nil {
+			if _, err := y.Renew(y.ctx); err != nil {
 				log.Error().
 					Err(err).
 					Str("session_id", y.id).
 					Uint32("yk_serial", y.serial).
-					Msg("yubikey ping failed")
+					Time("expires_at", y.ExpiresAt()).
+					Msg("yubikey lease renew failed")
+				continue
+			}
+
+			if missingSince := y.MissingSince(); !missingSince.IsZero() {
+				log.Warn().
+					Str("session_id", y.id).
+					Uint32("yk_serial", y.serial).
+					Time("missing_since", missingSince).
+					Msg("yubikey is missing")
+			}
+
+			if newTick := y.pingInterval(); newTick != tick {
+				tick = newTick
+				ticker.Reset(tick)
 			}
 		}
 	}
 }
+
+func (y *Yubikey) pingInterval() time.Duration {
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	return y.pingTick
+}
+
+func (y *Yubikey) updateStatus(status LeaseStatus) {
+	if status.ID != y.id {
+		return
+	}
+
+	y.mu.Lock()
+	defer y.mu.Unlock()
+
+	if !status.ExpiresAt.IsZero() {
+		y.expiresAt = status.ExpiresAt
+	}
+
+	y.missingSince = time.Time{}
+	if status.Missing {
+		y.missingSince = status.MissingSince
+	}
+}